@@ -0,0 +1,104 @@
+package gospeak
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is a failed response from a provider's API. Error gives the
+// human-readable message from the body where the provider's error shape
+// is known, the raw body otherwise, and the status text if the body is
+// empty.
+type APIError struct {
+	StatusCode int
+	Message    string // parsed from Body; empty if the shape wasn't recognized
+	Body       []byte // raw response body
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.TrimSpace(string(e.Body))
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, msg)
+}
+
+// Error bodies of the providers, merged into one shape since their field
+// names don't collide
+type apiErrorBody struct {
+	// OpenAI {"error": {"code", "message"}}; Google {"error": {"status", "message"}};
+	// Watson {"code", "error": "..."}
+	Error json.RawMessage `json:"error"`
+	// ElevenLabs {"detail": {"status", "message"}} or {"detail": "..."}
+	Detail json.RawMessage `json:"detail"`
+	// Deepgram {"err_code", "err_msg"}
+	ErrCode string `json:"err_code"`
+	ErrMsg  string `json:"err_msg"`
+	// Polly {"message"} or {"Message"}; Play.ht {"error_message"}
+	Message      string `json:"message"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// apiErrorMessage extracts "code: message" from a JSON error body, or
+// returns "" if it isn't one of the known shapes.
+func apiErrorMessage(body []byte) string {
+	var b apiErrorBody
+	if json.Unmarshal(body, &b) != nil {
+		return ""
+	}
+	switch msg := errorFieldMessage(b.Error); {
+	case msg != "":
+		return msg
+	case len(b.Detail) > 0:
+		var detail struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		var text string
+		if json.Unmarshal(b.Detail, &detail) == nil && detail.Message != "" {
+			return withCode(detail.Status, detail.Message)
+		} else if json.Unmarshal(b.Detail, &text) == nil {
+			return text
+		}
+	case b.ErrMsg != "":
+		return withCode(b.ErrCode, b.ErrMsg)
+	case b.ErrorMessage != "":
+		return b.ErrorMessage
+	case b.Message != "":
+		return b.Message
+	}
+	return ""
+}
+
+// errorFieldMessage reads the "error" field, an object with a message
+// or a plain string.
+func errorFieldMessage(raw json.RawMessage) string {
+	var e struct {
+		Message string `json:"message"`
+		Code    any    `json:"code"`
+		Status  string `json:"status"`
+	}
+	var text string
+	if json.Unmarshal(raw, &e) == nil && e.Message != "" {
+		code := e.Status
+		if s, ok := e.Code.(string); ok && s != "" {
+			code = s
+		}
+		return withCode(code, e.Message)
+	} else if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	return ""
+}
+
+func withCode(code, message string) string {
+	if code == "" {
+		return message
+	}
+	return code + ": " + message
+}
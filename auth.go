@@ -0,0 +1,148 @@
+package gospeak
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// authScheme applies a provider's credentials to an outgoing request.
+type authScheme interface {
+	applyAuth(req *http.Request)
+}
+
+// headerAuth sends the key in a request header, optionally prefixed
+// (e.g. "Bearer ").
+type headerAuth struct {
+	header string
+	prefix string
+	key    string
+}
+
+func (a headerAuth) applyAuth(req *http.Request) {
+	req.Header.Set(a.header, a.prefix+a.key)
+}
+
+// queryAuth sends the key as a URL query parameter.
+type queryAuth struct {
+	param string
+	key   string
+}
+
+func (a queryAuth) applyAuth(req *http.Request) {
+	q := req.URL.Query()
+	q.Set(a.param, a.key)
+	req.URL.RawQuery = q.Encode()
+}
+
+// basicAuth sends HTTP basic credentials.
+type basicAuth struct {
+	user     string
+	password string
+}
+
+func (a basicAuth) applyAuth(req *http.Request) {
+	req.SetBasicAuth(a.user, a.password)
+}
+
+// noAuth sends no credentials, for URLs that carry their own, such as
+// the signed links some providers return audio at.
+type noAuth struct{}
+
+func (noAuth) applyAuth(*http.Request) {}
+
+func bearerAuth(key string) authScheme {
+	return headerAuth{header: "Authorization", prefix: "Bearer ", key: key}
+}
+
+// sigV4Auth signs requests with AWS Signature Version 4.
+type sigV4Auth struct {
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	region       string
+	service      string
+}
+
+func (a sigV4Auth) applyAuth(req *http.Request) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	var payload []byte
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			payload, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	// Canonical headers must be lower-case and sorted by name
+	headers := map[string]string{
+		"host":       req.URL.Host,
+		"x-amz-date": amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+	if a.sessionToken != "" {
+		headers["x-amz-security-token"] = a.sessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + a.region + "/" + a.service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+a.secretKey), date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, a.service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,104 @@
+package gospeak
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Azure Speech defaults
+	DefaultAzureVoice = "en-US-JennyNeural"
+	AzureMaxChars     = 5000
+	azureAPIURL       = "https://%s.tts.speech.microsoft.com/cognitiveservices/v1"
+)
+
+// Azure voice presets (short name -> neural voice name)
+var AzureVoices = map[string]string{
+	"jenny":      "en-US-JennyNeural",
+	"guy":        "en-US-GuyNeural",
+	"aria":       "en-US-AriaNeural",
+	"davis":      "en-US-DavisNeural",
+	"jane":       "en-US-JaneNeural",
+	"jason":      "en-US-JasonNeural",
+	"sara":       "en-US-SaraNeural",
+	"tony":       "en-US-TonyNeural",
+	"ava":        "en-US-AvaNeural",
+	"andrew":     "en-US-AndrewNeural",
+	"emma":       "en-US-EmmaNeural",
+	"brian":      "en-US-BrianNeural",
+	"uk-sonia":   "en-GB-SoniaNeural",
+	"uk-ryan":    "en-GB-RyanNeural",
+	"au-natasha": "en-AU-NatashaNeural",
+	"au-william": "en-AU-WilliamNeural",
+}
+
+func resolveAzureVoice(voice string) string {
+	// Check if it's a preset name
+	if name, ok := AzureVoices[strings.ToLower(voice)]; ok {
+		return name
+	}
+	// Otherwise assume it's a full voice name (e.g., en-US-JennyNeural)
+	return voice
+}
+
+// azureSSML builds the SSML document Azure requires: the text, escaped,
+// spoken by voice at speed. An SSML fragment from the caller is placed
+// inside the voice element as is; a complete <speak> document is sent
+// unchanged and must name its own voice.
+func azureSSML(req Request, voice string) string {
+	text := req.Text
+	if req.SSML {
+		if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "<speak") {
+			return trimmed
+		}
+	} else {
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(text))
+		text = escaped.String()
+	}
+	if req.Speed != DefaultSpeed {
+		text = "<prosody rate='" + strconv.FormatFloat(req.Speed, 'f', -1, 64) + "'>" + text + "</prosody>"
+	}
+	// The language is the voice name's prefix, as with Google
+	return fmt.Sprintf("<speak version='1.0' xml:lang='%s'><voice name='%s'>%s</voice></speak>",
+		googleLanguageCode(voice), voice, text)
+}
+
+// AzureProvider synthesizes speech with the Azure AI Speech REST API,
+// which takes the text as SSML. It authenticates with the resource key,
+// or with a Token from the issueToken endpoint if one is set. The voice
+// name implies the model, so Request.Model is ignored.
+type AzureProvider struct {
+	APIKey string
+	Token  string // bearer token, used instead of APIKey
+	Region string // the Speech resource's region, e.g. eastus; required
+	HTTPOptions
+}
+
+func (p AzureProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p AzureProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultAzureVoice, "", DefaultFormat("azure"))
+	if p.Region == "" {
+		return nil, errors.New("azure needs the region of the Speech resource")
+	}
+
+	var auth authScheme = headerAuth{header: "Ocp-Apim-Subscription-Key", key: p.APIKey}
+	if p.Token != "" {
+		auth = bearerAuth(p.Token)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/ssml+xml")
+	header.Set("X-Microsoft-OutputFormat", formatToken("azure", req.Format))
+
+	ssml := azureSSML(req, resolveAzureVoice(req.Voice))
+	return p.send(ctx, "POST", fmt.Sprintf(azureAPIURL, p.Region), auth, header, strings.NewReader(ssml))
+}
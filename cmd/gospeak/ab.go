@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/schappim/gospeak"
+)
+
+// maxABSetups is how many setups --ab can compare, one per letter.
+const maxABSetups = 26
+
+// stringList is a flag that can be given more than once, collecting each
+// value in order.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// abSetup is one provider and voice compared by --ab. An empty voice
+// means the provider's default.
+type abSetup struct {
+	provider string
+	voice    string
+}
+
+// parseABSetups parses --ab values, each "provider" or "provider:voice".
+func parseABSetups(specs []string) ([]abSetup, error) {
+	if len(specs) < 2 {
+		return nil, fmt.Errorf("--ab needs at least two setups to compare, e.g. --ab openai:nova --ab elevenlabs:rachel")
+	}
+	if len(specs) > maxABSetups {
+		return nil, fmt.Errorf("--ab can compare at most %d setups", maxABSetups)
+	}
+	setups := make([]abSetup, len(specs))
+	for i, spec := range specs {
+		provider, voice, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		provider = strings.ToLower(provider)
+		if !isValidProvider(provider) {
+			return nil, fmt.Errorf("Invalid provider '%s' in --ab %s. Use %s", provider, spec, providerChoices())
+		}
+		setups[i] = abSetup{provider: provider, voice: strings.TrimSpace(voice)}
+	}
+	return setups, nil
+}
+
+// abLabel is the letter --ab announces setup i with.
+func abLabel(i int) string {
+	return string(rune('A' + i))
+}
+
+// abRequests turns setups into requests for base's text. The first setup
+// is base itself, already checked like any single request; the others
+// take base's settings where their provider accepts them and its
+// defaults otherwise.
+func abRequests(base speechRequest, setups []abSetup) ([]speechRequest, error) {
+	reqs := []speechRequest{base}
+	for i, s := range setups[1:] {
+		label := abLabel(i + 1)
+		r := base
+		r.provider = s.provider
+		r.apiKey = apiKeyFor(s.provider, "")
+		if r.apiKey == "" && needsAPIKey(s.provider) && !(s.provider == "openai" && openAIBaseURL != "") {
+			return nil, fmt.Errorf("%s environment variable not set, needed for --ab setup %s", providerEnvVars[s.provider], label)
+		}
+		r.voice = s.voice
+		if r.voice == "" {
+			r.voice = defaultVoiceFor(s.provider)
+		}
+		if s.provider == "resemble" && r.voice == "" {
+			return nil, fmt.Errorf("--ab setup %s needs a voice for Resemble, e.g. resemble:<voice UUID>", label)
+		}
+		if _, err := resolveVoices(s.provider, r.voice); err != nil {
+			return nil, err
+		}
+		r.model = defaultModelFor(s.provider)
+		if s.provider == "piper" && r.model == "" {
+			return nil, fmt.Errorf("PIPER_MODEL is required for --ab setup %s (path to a .onnx voice model)", label)
+		}
+		if r.ssml && !supportsSSML(s.provider) {
+			return nil, fmt.Errorf("--ab setup %s: %s does not support SSML", label, s.provider)
+		}
+		if gospeak.ValidateFormat(s.provider, r.format) != nil {
+			r.format = gospeak.DefaultFormat(s.provider)
+		}
+		if r.speed != defaultSpeed && (!supportsSpeed(s.provider) || validateSpeed(s.provider, r.speed) != nil) {
+			fmt.Fprintf(os.Stderr, "Warning: %s can't speak at --speed %s; setup %s uses normal speed\n", s.provider, formatSpeed(r.speed), label)
+			r.speed = defaultSpeed
+		}
+		// The sample rate and bitrate were checked for the first
+		// provider only
+		if s.provider != base.provider {
+			r.sampleRate, r.bitrate = 0, 0
+		}
+		reqs = append(reqs, r)
+	}
+	return reqs, nil
+}
+
+// speakAB plays reqs one after another for --ab, each preceded by its
+// setup's voice saying the setup's letter, gap apart. Clips and letters
+// are cached like any other speech, so auditioning the same text again
+// costs nothing. They are synthesized ahead in the background,
+// concurrency at a time, so each setup is ready by the time the one
+// before it finishes playing. onDone is called for each request that
+// succeeds.
+func speakAB(ctx context.Context, reqs []speechRequest, useCache bool, gap time.Duration, concurrency int, onDone func(r speechRequest)) {
+	// Each setup's letter, then its text
+	var clips []speechRequest
+	for i, r := range reqs {
+		marker := r
+		marker.text = abLabel(i)
+		marker.ssml = false
+		clips = append(clips, marker, r)
+	}
+
+	cached := make([][]byte, len(clips))
+	var pending []speechRequest
+	pendingAt := make([]int, len(clips))
+	for i, r := range clips {
+		if useCache {
+			var ok bool
+			cached[i], ok = cacheLoad(r.cacheKey(), r.format)
+			appMetrics.observeCache(r.provider, r.voice, ok)
+		}
+		if cached[i] == nil {
+			pendingAt[i] = len(pending)
+			pending = append(pending, r)
+		}
+	}
+	wait := startSynthesis(ctx, pending, concurrency, nil)
+
+	// clip returns clips[i], from the cache or once it is synthesized
+	clip := func(i int) ([]byte, error) {
+		if cached[i] != nil {
+			return cached[i], nil
+		}
+		audioData, err := wait(pendingAt[i])
+		if err != nil {
+			return nil, err
+		}
+		onDone(clips[i])
+		if useCache {
+			if err := cacheStore(clips[i].cacheKey(), clips[i], audioData); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+			}
+		}
+		return audioData, nil
+	}
+	pause := func(d time.Duration) {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+
+	for i, r := range reqs {
+		fmt.Fprintf(os.Stderr, "%s: %s (%s)\n", abLabel(i), r.provider, r.voice)
+		if marker, err := clip(2 * i); err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error synthesizing setup %s's letter: %v\n", abLabel(i), err)
+		} else if err := gospeak.Play(ctx, marker); err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+		} else {
+			pause(gap / 2)
+		}
+
+		audioData, err := clip(2*i + 1)
+		if err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error synthesizing setup %s with %s: %v\n", abLabel(i), r.provider, err)
+			continue
+		}
+		// Deepgram can't change speed itself, so resample as for one
+		// voice
+		if resamplesSpeed(r.provider) && r.speed != defaultSpeed {
+			if audioData, err = gospeak.ChangeSpeed(audioData, r.speed); err != nil {
+				fmt.Fprintf(os.Stderr, "Error changing speed: %v\n", err)
+				continue
+			}
+		}
+		if err := gospeak.Play(ctx, audioData); err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+		}
+		if i < len(reqs)-1 {
+			pause(gap)
+		}
+	}
+}
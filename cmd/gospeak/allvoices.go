@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/schappim/gospeak"
+)
+
+// presetVoiceNames returns the names of the provider's built-in voices,
+// the set --all goes through.
+func presetVoiceNames(provider string) []string {
+	var names []string
+	for _, v := range gospeak.BuiltinVoices(provider) {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// resolveVoices parses a comma-separated --voice or --voices value such
+// as "alloy,nova,shimmer", dropping duplicates, and checks each voice
+// against the provider's names where they are known up front. ElevenLabs
+// names are looked up in the account later, and other providers take any
+// voice.
+func resolveVoices(provider, csv string) ([]string, error) {
+	var voices []string
+	seen := map[string]bool{}
+	for _, v := range strings.Split(csv, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		if provider == "openai" && openAIBaseURL == "" && !gospeak.IsValidOpenAIVoice(v) {
+			return nil, fmt.Errorf("Invalid OpenAI voice '%s'. Valid voices: %s", v, strings.Join(gospeak.OpenAIVoices, ", "))
+		}
+		if provider == "deepgram" && !gospeak.IsValidDeepgramVoice(v) {
+			return nil, fmt.Errorf("Invalid Deepgram voice '%s'. Use a preset (%s) or a full model name like %s", v, strings.Join(deepgramPresets(), ", "), gospeak.DefaultDeepgramVoice)
+		}
+		seen[v] = true
+		voices = append(voices, v)
+	}
+	return voices, nil
+}
+
+// voicePaths names each voice's file in dir from tmpl, by default
+// "{{.Voice}}.{{.Ext}}". Two voices may not share a file.
+func voicePaths(base speechRequest, voices []string, dir, tmpl string) ([]string, error) {
+	if tmpl == "" {
+		tmpl = "{{.Voice}}.{{.Ext}}"
+	}
+	paths := make([]string, len(voices))
+	seen := map[string]bool{}
+	for i, v := range voices {
+		r := base
+		r.voice = v
+		hash := r.cacheKey()
+		name, err := renderOutputName(tmpl, outputNameData{
+			Hash:      hash,
+			ShortHash: hash[:12],
+			Provider:  r.provider,
+			Voice:     v,
+			Model:     r.model,
+			Ext:       r.format,
+			Date:      time.Now().Format(time.DateOnly),
+		})
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = filepath.Join(dir, name)
+		if seen[paths[i]] {
+			return nil, fmt.Errorf("--output-template names more than one voice's file %s; include {{.Voice}}", paths[i])
+		}
+		seen[paths[i]] = true
+	}
+	return paths, nil
+}
+
+// speakWithVoices speaks base's text with each of voices in turn, for
+// --all and --voices. When play is set each clip is played, gap apart,
+// and with announce it is preceded by the voice saying its own name;
+// announcements are cached, so later runs don't pay for them again. When
+// paths is non-nil each voice's clip is also saved to its path; with
+// noClobber, a voice whose file exists is not synthesized again. Clips
+// are synthesized ahead in the background, concurrency at a time, and
+// handled in order. onDone is called for each request that succeeds.
+func speakWithVoices(ctx context.Context, base speechRequest, voices, paths []string, play, announce, noClobber, useCache bool, gap time.Duration, concurrency int, onDone func(r speechRequest)) {
+	existing := make([][]byte, len(voices))
+	if noClobber {
+		for i, path := range paths {
+			existing[i], _ = os.ReadFile(path)
+		}
+	}
+
+	var reqs []speechRequest
+	announcements := make([]speechRequest, len(voices))
+	announced := make([][]byte, len(voices))
+	announceAt := make([]int, len(voices))
+	textAt := make([]int, len(voices))
+	for i, v := range voices {
+		r := base
+		r.voice = v
+		if play && announce {
+			announcements[i] = r
+			announcements[i].text = v
+			announcements[i].ssml = false
+			if useCache {
+				announced[i], _ = cacheLoad(announcements[i].cacheKey(), r.format)
+			}
+			if announced[i] == nil {
+				announceAt[i] = len(reqs)
+				reqs = append(reqs, announcements[i])
+			}
+		}
+		if existing[i] == nil {
+			textAt[i] = len(reqs)
+			reqs = append(reqs, r)
+		}
+	}
+	wait := startSynthesis(ctx, reqs, concurrency, nil)
+
+	// pause waits d between clips, returning early if interrupted
+	pause := func(d time.Duration) {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+	}
+
+	for i, v := range voices {
+		var err error
+		if play {
+			fmt.Fprintf(os.Stderr, "Speaking with voice: %s\n", v)
+		}
+		if play && announce {
+			audioData := announced[i]
+			if audioData == nil {
+				audioData, err = wait(announceAt[i])
+				if err == nil {
+					onDone(announcements[i])
+					if useCache {
+						if err := cacheStore(announcements[i].cacheKey(), announcements[i], audioData); err != nil {
+							fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+						}
+					}
+				}
+			}
+			if err != nil {
+				exitIfInterrupted(ctx, err)
+				fmt.Fprintf(os.Stderr, "Error synthesizing voice announcement: %v\n", err)
+			} else {
+				err = gospeak.Play(ctx, audioData)
+				if err != nil {
+					exitIfInterrupted(ctx, err)
+					fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+				} else {
+					pause(gap / 2)
+				}
+			}
+		}
+
+		textData, textErr := existing[i], error(nil)
+		if textData != nil {
+			fmt.Fprintf(os.Stderr, "Skipping synthesis, %s already exists\n", paths[i])
+		} else {
+			// The text was requested either way, so it still counts
+			// towards usage when the announcement failed
+			textData, textErr = wait(textAt[i])
+			if textErr == nil {
+				onDone(reqs[textAt[i]])
+			}
+		}
+		if textErr != nil {
+			exitIfInterrupted(ctx, textErr)
+			fmt.Fprintf(os.Stderr, "Error synthesizing: %v\n", textErr)
+			continue
+		}
+		// Deepgram can't change speed itself, so resample as for one
+		// voice; only WAV is saved at the new speed
+		played := textData
+		if resamplesSpeed(base.provider) && base.speed != defaultSpeed && existing[i] == nil {
+			if played, textErr = gospeak.ChangeSpeed(textData, base.speed); textErr != nil {
+				fmt.Fprintf(os.Stderr, "Error changing speed: %v\n", textErr)
+				continue
+			}
+			if base.format == "wav" {
+				textData = played
+			}
+		}
+		if paths != nil && existing[i] == nil {
+			if textData, textErr = processSaved(textData, base.format); textErr != nil {
+				fmt.Fprintf(os.Stderr, "Error processing audio: %v\n", textErr)
+				continue
+			}
+			if err := writeFileAtomic(paths[i], textData, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving file: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Saved to %s\n", paths[i])
+		}
+		if !play || err != nil {
+			continue
+		}
+		if err := gospeak.Play(ctx, played); err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+		}
+		if i < len(voices)-1 {
+			pause(gap)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/schappim/gospeak"
+)
+
+// batchLines returns the lines of text to synthesize in --batch mode,
+// skipping blank lines and # comments.
+func batchLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// batchFileName returns the numbered file name for line i (from 0) of n:
+// 001.mp3, 002.mp3, and so on, with more digits for longer batches.
+func batchFileName(i, n int, format string) string {
+	width := max(len(fmt.Sprint(n)), 3)
+	return fmt.Sprintf("%0*d.%s", width, i+1, format)
+}
+
+// runBatch synthesizes each line into its own numbered file in dir, with up
+// to concurrency lines in flight at once, reporting progress on stderr
+// unless --quiet is set. Files are written in line order. A failed line is
+// reported and skipped, so one bad line doesn't lose the rest of the batch.
+// With play, each saved line is also queued to play, in order, while the
+// lines after it synthesize. It returns the number of lines that failed.
+func runBatch(ctx context.Context, base speechRequest, lines []string, dir string, concurrency int, useCache, noClobber, play bool, onDone func(r speechRequest)) int {
+	// Settle what can be answered locally first, so only the lines that
+	// need the provider go to the worker pool
+	reqs := make([]speechRequest, len(lines))
+	audio := make([][]byte, len(lines))
+	skip := make([]bool, len(lines))
+	var pending []int
+	for i, line := range lines {
+		reqs[i] = base
+		reqs[i].text = line
+		if noClobber {
+			if _, err := os.Stat(filepath.Join(dir, batchFileName(i, len(lines), base.format))); err == nil {
+				skip[i] = true
+				continue
+			}
+		}
+		if useCache {
+			var cached bool
+			audio[i], cached = cacheLoad(reqs[i].cacheKey(), reqs[i].format)
+			appMetrics.observeCache(reqs[i].provider, reqs[i].voice, cached)
+			if cached {
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	pendingReqs := make([]speechRequest, len(pending))
+	for j, i := range pending {
+		pendingReqs[j] = reqs[i]
+	}
+	wait := startSynthesis(ctx, pendingReqs, concurrency, nil)
+	var queue *playQueue
+	if play {
+		queue = newPlayQueue(ctx, concurrency)
+	}
+
+	failed := 0
+	j := 0
+	for i := range lines {
+		name := batchFileName(i, len(lines), base.format)
+		progress := fmt.Sprintf("[%d/%d] %s", i+1, len(lines), name)
+		if skip[i] {
+			fmt.Fprintf(os.Stderr, "%s: already exists, skipping\n", progress)
+			continue
+		}
+
+		r := reqs[i]
+		if j < len(pending) && pending[j] == i {
+			stop := showProgress(progress + ": synthesizing")
+			audioData, err := wait(j)
+			stop()
+			j++
+			if err != nil {
+				exitIfInterrupted(ctx, err)
+				fmt.Fprintf(os.Stderr, "%s: Error synthesizing: %v\n", progress, err)
+				failed++
+				continue
+			}
+			onDone(r)
+			if useCache {
+				if err := cacheStore(r.cacheKey(), r, audioData); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+				}
+			}
+			audio[i] = audioData
+		}
+
+		// The cache holds the audio as the provider sent it, so cached
+		// lines are processed just like new ones
+		var err error
+		if audio[i], err = processSaved(audio[i], r.format); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: Error processing audio: %v\n", progress, err)
+			failed++
+			continue
+		}
+
+		if err := writeFileAtomic(filepath.Join(dir, name), audio[i], 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: Error saving file: %v\n", progress, err)
+			failed++
+			continue
+		}
+		if !quiet {
+			fmt.Fprintln(os.Stderr, progress)
+		}
+		if queue != nil {
+			clip := audio[i]
+			if resamplesSpeed(r.provider) && r.speed != defaultSpeed {
+				var err error
+				if clip, err = gospeak.ChangeSpeed(clip, r.speed); err != nil {
+					fmt.Fprintf(os.Stderr, "%s: Error changing speed: %v\n", progress, err)
+					continue
+				}
+			}
+			queue.add(clip)
+		}
+	}
+	if queue != nil {
+		exitIfInterrupted(ctx, queue.wait())
+	}
+	return failed
+}
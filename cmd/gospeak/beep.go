@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+
+	"github.com/schappim/gospeak"
+)
+
+// Built-in error tone: a short two-note alert stored as raw 16-bit
+// little-endian stereo PCM, so it can be played without any decoder.
+//
+//go:embed assets/error_tone.pcm
+var errorTone []byte
+
+const errorToneSampleRate = 22050
+
+// beepOnError is set by --beep-on-error.
+var beepOnError bool
+
+// tonePlayed guards against recursion: the tone is never tried twice.
+var tonePlayed bool
+
+// exit terminates the program, playing the error tone first when
+// --beep-on-error is set and the exit code signals a failure. Temp files
+// left by an interrupted writeFileAtomic are removed.
+func exit(code int) {
+	removeTempFiles()
+	if code != 0 && beepOnError && !tonePlayed && !gospeak.DeviceFailed() {
+		playErrorTone()
+	}
+	os.Exit(code)
+}
+
+// playErrorTone plays the embedded error tone through the shared audio
+// context. Any failure is ignored, since we are already on an error path.
+func playErrorTone() {
+	tonePlayed = true
+
+	gospeak.PlayPCM(context.Background(), bytes.NewReader(errorTone), errorToneSampleRate)
+}
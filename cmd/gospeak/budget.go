@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Approximate published pricing in USD per million characters.
+// Keys are "provider/model"; a bare "provider" entry is the fallback.
+var providerPricing = map[string]float64{
+	"openai/tts-1":    15.0,
+	"openai/tts-1-hd": 30.0,
+	"openai":          30.0,
+	"elevenlabs":      300.0,
+	"deepgram/aura-2": 30.0,
+	"deepgram":        15.0,
+	"google":          16.0,
+	"polly/standard":  4.0,
+	"polly":           16.0,
+	"azure":           15.0,
+	"playht":          50.0,
+	"watson":          20.0,
+	"lmnt":            50.0,
+	"resemble":        40.0,
+	"piper":           0.0,
+	"say":             0.0,
+	"espeak":          0.0,
+
+	// gpt-4o-mini-tts is billed per token, roughly $0.015 per minute of speech
+	"openai/gpt-4o-mini-tts": 15.0,
+}
+
+// estimateCost returns the approximate cost in USD of synthesizing chars
+// characters with the given provider and model.
+func estimateCost(provider, model string, chars int) float64 {
+	rate, ok := providerPricing[provider+"/"+model]
+	if !ok && provider == "deepgram" && strings.HasPrefix(model, "aura-2-") {
+		rate, ok = providerPricing["deepgram/aura-2"]
+	}
+	if !ok {
+		rate = providerPricing[provider]
+	}
+	return rate * float64(chars) / 1_000_000
+}
+
+// parseBudget parses a budget such as "$10/month", "10" or "$2.50".
+// Only monthly budgets are supported.
+func parseBudget(s string) (float64, error) {
+	v := strings.TrimSpace(s)
+	if i := strings.Index(v, "/"); i >= 0 {
+		period := strings.ToLower(strings.TrimSpace(v[i+1:]))
+		if period != "month" && period != "mo" {
+			return 0, fmt.Errorf("unsupported budget period '%s' (only /month is supported)", period)
+		}
+		v = v[:i]
+	}
+	v = strings.TrimPrefix(strings.TrimSpace(v), "$")
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid budget '%s'", s)
+	}
+	return amount, nil
+}
+
+// usageRecord is the persisted monthly usage counter.
+type usageRecord struct {
+	Month      string         `json:"month"`
+	Characters map[string]int `json:"characters"`
+	Spend      float64        `json:"spend"`
+
+	// path is where save writes; empty when the file couldn't be read,
+	// so a broken counter is never replaced by a fresh one
+	path string
+}
+
+func usagePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gospeak", "usage.json"), nil
+}
+
+// loadUsage reads the usage counter for the month containing now. A
+// counter from a previous month is discarded, so the budget resets
+// automatically at month boundaries. On error the record starts from zero
+// and is never saved.
+func loadUsage(now time.Time) (*usageRecord, error) {
+	month := now.Format("2006-01")
+	u := &usageRecord{Month: month, Characters: map[string]int{}}
+	path, err := usagePath()
+	if err != nil {
+		return u, fmt.Errorf("failed to locate config dir: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		u.path = path
+		return u, nil
+	}
+	if err != nil {
+		return u, fmt.Errorf("failed to read usage: %w", err)
+	}
+
+	var stored usageRecord
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return u, fmt.Errorf("failed to parse usage in %s: %w", path, err)
+	}
+	u.path = path
+	if stored.Month == month {
+		u.Spend = stored.Spend
+		for k, v := range stored.Characters {
+			u.Characters[k] = v
+		}
+	}
+	return u, nil
+}
+
+// openUsage loads the month's usage for a run. With a --budget the spend
+// must be known to be enforced, so a counter that can't be read is fatal;
+// without one it's only a warning, and usage goes unrecorded.
+func openUsage(budget float64) *usageRecord {
+	usage, err := loadUsage(time.Now())
+	if err != nil {
+		if budget > 0 {
+			fmt.Fprintf(os.Stderr, "Error: %v; fix or remove the file to use --budget\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return usage
+}
+
+// add records chars characters synthesized with provider.
+func (u *usageRecord) add(provider, model string, chars int) {
+	u.Characters[provider] += chars
+	u.Spend += estimateCost(provider, model, chars)
+}
+
+func (u *usageRecord) save() error {
+	// loadUsage has already warned
+	if u.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(u, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(u.path), 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+	return writeFileAtomic(u.path, data, 0644)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The audio cache lives in the user cache dir. Each entry is an audio file
+// named after its cache key plus a JSON sidecar describing it:
+//
+//	<key>.mp3   the synthesized audio (or .wav, .opus, ...)
+//	<key>.json  cacheMeta
+//
+// The audio file's modification time is bumped on every cache hit, so it
+// doubles as the last-access time used for eviction.
+
+// cacheMeta describes a cached clip.
+type cacheMeta struct {
+	Provider string    `json:"provider"`
+	Voice    string    `json:"voice"`
+	Model    string    `json:"model,omitempty"`
+	Format   string    `json:"format"`
+	Created  time.Time `json:"created"`
+}
+
+// cacheEntry is a cached clip found on disk.
+type cacheEntry struct {
+	key       string
+	meta      cacheMeta
+	size      int64
+	accessed  time.Time
+	audioPath string
+	metaPath  string
+}
+
+func cacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache dir: %w", err)
+	}
+	return filepath.Join(dir, "gospeak"), nil
+}
+
+// listCacheEntries returns all complete entries in dir. A missing cache
+// directory is treated as empty.
+func listCacheEntries(dir string) ([]cacheEntry, error) {
+	metaFiles, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []cacheEntry
+	for _, metaPath := range metaFiles {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+		var meta cacheMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+		key := strings.TrimSuffix(filepath.Base(metaPath), ".json")
+		audioPath := filepath.Join(dir, key+"."+meta.Format)
+		info, err := os.Stat(audioPath)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, cacheEntry{
+			key:       key,
+			meta:      meta,
+			size:      info.Size(),
+			accessed:  info.ModTime(),
+			audioPath: audioPath,
+			metaPath:  metaPath,
+		})
+	}
+	return entries, nil
+}
+
+// cacheKey returns the key r's audio is cached under. Settings beyond the
+// common ones are folded in only where they change the audio.
+func (r speechRequest) cacheKey() string {
+	var extra []string
+	if r.ssml {
+		extra = append(extra, "ssml")
+	}
+	if r.provider == "openai" && r.instructions != "" {
+		extra = append(extra, "instructions:"+r.instructions)
+	}
+	if r.provider == "openai" && openAIBaseURL != "" {
+		extra = append(extra, openAIBaseURL)
+	}
+	if r.seed != 0 && supportsSeed(r.provider) {
+		extra = append(extra, "seed:"+strconv.FormatUint(uint64(r.seed), 10))
+	}
+	if (r.provider == "deepgram" || r.provider == "lmnt" || r.provider == "elevenlabs") && r.sampleRate > 0 {
+		extra = append(extra, "rate:"+strconv.Itoa(r.sampleRate))
+	}
+	if r.provider == "elevenlabs" && r.bitrate > 0 {
+		extra = append(extra, "bitrate:"+strconv.Itoa(r.bitrate))
+	}
+	if r.provider == "elevenlabs" {
+		extra = append(extra, strconv.FormatFloat(r.stability, 'f', -1, 64), strconv.FormatFloat(r.similarityBoost, 'f', -1, 64))
+		if r.style != 0 {
+			extra = append(extra, "style:"+strconv.FormatFloat(r.style, 'f', -1, 64))
+		}
+		if r.speakerBoost != nil {
+			extra = append(extra, "speaker-boost:"+strconv.FormatBool(*r.speakerBoost))
+		}
+		if r.language != "" {
+			extra = append(extra, "lang:"+r.language)
+		}
+	}
+	return cacheKey(r.provider, r.model, r.voice, r.speed, r.format, r.text, extra...)
+}
+
+// cacheLoad returns the cached audio for key, bumping its access time.
+func cacheLoad(key, format string) ([]byte, bool) {
+	dir, err := cacheDir()
+	if err != nil {
+		return nil, false
+	}
+	audioPath := filepath.Join(dir, key+"."+format)
+	data, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := os.Stat(filepath.Join(dir, key+".json")); err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(audioPath, now, now)
+	return data, true
+}
+
+// cacheStore saves audio synthesized for r under key.
+func cacheStore(key string, r speechRequest, audioData []byte) error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	meta, err := json.MarshalIndent(cacheMeta{
+		Provider: r.provider,
+		Voice:    r.voice,
+		Model:    r.model,
+		Format:   r.format,
+		Created:  time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Audio first: an entry only counts once its sidecar exists
+	if err := writeFileAtomic(filepath.Join(dir, key+"."+r.format), audioData, 0644); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, key+".json"), meta, 0644)
+}
+
+// cacheClear removes every entry from the cache.
+func cacheClear(dir string) error {
+	entries, err := listCacheEntries(dir)
+	if err != nil {
+		return err
+	}
+	var freed int64
+	for _, e := range entries {
+		if err := e.remove(); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", e.key, err)
+		}
+		freed += e.size
+	}
+	fmt.Printf("Cleared %d entries, freed %s\n", len(entries), formatSize(freed))
+	return nil
+}
+
+func (e cacheEntry) remove() error {
+	if err := os.Remove(e.audioPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return os.Remove(e.metaPath)
+}
+
+// runCacheCommand implements "gospeak cache <stats|prune|clear>".
+func runCacheCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: gospeak cache <stats|prune|clear> [options]")
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "stats":
+		return cacheStats(dir)
+	case "clear":
+		return cacheClear(dir)
+	case "prune":
+		fs := flag.NewFlagSet("cache prune", flag.ExitOnError)
+		olderThan := fs.String("older-than", "", "Evict entries not used for this long, e.g. 30d or 12h")
+		maxSize := fs.String("max-size", "", "Evict least recently used entries until the cache fits, e.g. 500MB")
+		fs.Parse(args[1:])
+
+		var age time.Duration
+		if *olderThan != "" {
+			if age, err = parseAge(*olderThan); err != nil {
+				return err
+			}
+		}
+		var size int64
+		if *maxSize != "" {
+			if size, err = parseSize(*maxSize); err != nil {
+				return err
+			}
+		}
+		if age == 0 && size == 0 {
+			return errors.New("cache prune needs --older-than and/or --max-size")
+		}
+		return cachePrune(dir, age, size)
+	}
+	return fmt.Errorf("unknown cache command '%s' (use stats, prune, or clear)", args[0])
+}
+
+func cacheStats(dir string) error {
+	entries, err := listCacheEntries(dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache:   %s\n", dir)
+	fmt.Printf("Entries: %d\n", len(entries))
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var total int64
+	oldest, newest := entries[0].meta.Created, entries[0].meta.Created
+	type group struct {
+		count int
+		size  int64
+	}
+	groups := map[string]*group{}
+	for _, e := range entries {
+		total += e.size
+		if e.meta.Created.Before(oldest) {
+			oldest = e.meta.Created
+		}
+		if e.meta.Created.After(newest) {
+			newest = e.meta.Created
+		}
+		name := e.meta.Provider + "/" + e.meta.Voice
+		if groups[name] == nil {
+			groups[name] = &group{}
+		}
+		groups[name].count++
+		groups[name].size += e.size
+	}
+
+	fmt.Printf("Size:    %s\n", formatSize(total))
+	fmt.Printf("Oldest:  %s\n", oldest.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("Newest:  %s\n", newest.Local().Format("2006-01-02 15:04"))
+	fmt.Println("By provider/voice:")
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-32s %5d  %s\n", name, groups[name].count, formatSize(groups[name].size))
+	}
+	return nil
+}
+
+// cachePrune evicts entries not accessed within maxAge, then the least
+// recently accessed entries until the cache is no larger than maxSize.
+// A zero limit is ignored.
+func cachePrune(dir string, maxAge time.Duration, maxSize int64) error {
+	entries, err := listCacheEntries(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].accessed.Before(entries[j].accessed) })
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	removed, freed := 0, int64(0)
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		tooOld := maxAge > 0 && e.accessed.Before(cutoff)
+		tooBig := maxSize > 0 && total > maxSize
+		if !tooOld && !tooBig {
+			continue
+		}
+		if err := e.remove(); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", e.key, err)
+		}
+		total -= e.size
+		freed += e.size
+		removed++
+	}
+
+	fmt.Printf("Pruned %d entries, freed %s (%s remaining)\n", removed, formatSize(freed), formatSize(total))
+	return nil
+}
+
+// parseAge parses a duration that may also use a "d" (days) suffix.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age '%s'", s)
+		}
+		return time.Duration(n * 24 * float64(time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age '%s'", s)
+	}
+	return d, nil
+}
+
+// parseSize parses a byte size such as "500MB", "1.5GB" or "2048".
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		mult   float64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	v := strings.ToUpper(strings.TrimSpace(s))
+	mult := 1.0
+	for _, u := range units {
+		if num, ok := strings.CutSuffix(v, u.suffix); ok {
+			v, mult = strings.TrimSpace(num), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size '%s'", s)
+	}
+	return int64(n * mult), nil
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
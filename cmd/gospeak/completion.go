@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/schappim/gospeak"
+)
+
+// Flags whose value is a path, completed with file names
+var fileFlags = map[string]bool{
+	"output": true, "o": true, "file": true, "f": true, "output-dir": true, "config": true,
+	"diff": true, "piper-bin": true, "timestamps-estimate": true, "token-file": true,
+	"dict": true, "timestamps": true, "srt": true,
+}
+
+// subcommands are offered as the first word.
+var subcommands = []string{"cache", "voices", "providers"}
+
+// runCompletionCommand implements the hidden "gospeak completion <shell>",
+// which prints a completion script for bash, zsh, or fish. The flags
+// come from the flag set, so it must run after they are defined; voice
+// names are the presets of each provider, picked by the --provider
+// already on the command line.
+func runCompletionCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: gospeak completion <bash|zsh|fish>")
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		// zsh runs the bash function through its compatibility layer
+		fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+		writeBashCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("unknown shell '%s'. Use bash, zsh, or fish", args[0])
+	}
+	return nil
+}
+
+// flagName formats a flag as typed: -p for a single letter, --provider
+// otherwise.
+func flagName(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// flagNames lists every flag as typed, sorted.
+func flagNames() []string {
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) {
+		names = append(names, flagName(f.Name))
+	})
+	sort.Strings(names)
+	return names
+}
+
+// valueFlags lists the flags that take a value, as typed, with the names
+// in only matching whichever of them are defined.
+func valueFlags(only map[string]bool) []string {
+	var names []string
+	flag.VisitAll(func(f *flag.Flag) {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			return
+		}
+		if only == nil || only[f.Name] {
+			names = append(names, flagName(f.Name))
+		}
+	})
+	sort.Strings(names)
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# bash completion for gospeak; load with: source <(gospeak completion bash)")
+	fmt.Fprintln(w, "_gospeak() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    local provider=openai i words`)
+	fmt.Fprintln(w, `    for ((i = 1; i < COMP_CWORD - 1; i++)); do`)
+	fmt.Fprintln(w, `        case "${COMP_WORDS[i]}" in`)
+	fmt.Fprintln(w, `            -p|--provider|-provider) provider="${COMP_WORDS[i+1]}" ;;`)
+	fmt.Fprintln(w, `        esac`)
+	fmt.Fprintln(w, `    done`)
+	fmt.Fprintln(w, `    case "$prev" in`)
+	fmt.Fprintf(w, "        -p|--provider|-provider) words=%q ;;\n", strings.Join(providerNames, " "))
+	fmt.Fprintln(w, `        -v|--voice|-voice)`)
+	fmt.Fprintln(w, `            case "$provider" in`)
+	for _, p := range providerNames {
+		if voices := presetVoiceNames(p); len(voices) > 0 {
+			fmt.Fprintf(w, "                %s) words=%q ;;\n", p, strings.Join(voices, " "))
+		}
+	}
+	fmt.Fprintln(w, `            esac ;;`)
+	fmt.Fprintf(w, "        --format|-format) words=%q ;;\n", strings.Join(gospeak.AudioFormats, " "))
+	if names := valueFlags(fileFlags); len(names) > 0 {
+		fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(names, "|"))
+	}
+	fmt.Fprintf(w, "        %s) return ;;\n", strings.Join(valueFlags(nil), "|"))
+	fmt.Fprintln(w, `        *)`)
+	fmt.Fprintln(w, `            if [[ "$cur" == -* ]]; then`)
+	fmt.Fprintf(w, "                words=%q\n", strings.Join(flagNames(), " "))
+	fmt.Fprintln(w, `            elif ((COMP_CWORD == 1)); then`)
+	fmt.Fprintf(w, "                words=%q\n", strings.Join(subcommands, " "))
+	fmt.Fprintln(w, `            else`)
+	fmt.Fprintln(w, `                return`)
+	fmt.Fprintln(w, `            fi ;;`)
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _gospeak gospeak")
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for gospeak; load with: gospeak completion fish | source")
+	fmt.Fprintln(w, "function __gospeak_voices")
+	fmt.Fprintln(w, "    set -l tokens (commandline -opc)")
+	fmt.Fprintln(w, "    set -l provider openai")
+	fmt.Fprintln(w, "    for i in (seq (math (count $tokens) - 1))")
+	fmt.Fprintln(w, "        if contains -- $tokens[$i] -p --provider -provider")
+	fmt.Fprintln(w, "            set provider $tokens[(math $i + 1)]")
+	fmt.Fprintln(w, "        end")
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, "    switch $provider")
+	for _, p := range providerNames {
+		if voices := presetVoiceNames(p); len(voices) > 0 {
+			fmt.Fprintf(w, "        case %s\n", p)
+			fmt.Fprintf(w, "            printf '%%s\\n' %s\n", strings.Join(voices, " "))
+		}
+	}
+	fmt.Fprintln(w, "    end")
+	fmt.Fprintln(w, "end")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -c gospeak -n __fish_use_subcommand -f -a %q\n", strings.Join(subcommands, " "))
+
+	flag.VisitAll(func(f *flag.Flag) {
+		opt := "-l " + f.Name
+		if len(f.Name) == 1 {
+			opt = "-s " + f.Name
+		}
+		args := ""
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+			switch {
+			case f.Name == "provider" || f.Name == "p":
+				args = fmt.Sprintf(" -x -a %q", strings.Join(providerNames, " "))
+			case f.Name == "voice" || f.Name == "v":
+				args = " -x -a '(__gospeak_voices)'"
+			case f.Name == "format":
+				args = fmt.Sprintf(" -x -a %q", strings.Join(gospeak.AudioFormats, " "))
+			case fileFlags[f.Name]:
+				args = " -r -F"
+			default:
+				args = " -x"
+			}
+		}
+		fmt.Fprintf(w, "complete -c gospeak %s%s -d %s\n", opt, args, fishQuote(f.Usage))
+	})
+}
+
+// fishQuote single-quotes s for fish, which only treats \ and ' specially
+// inside single quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
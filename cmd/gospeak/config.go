@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// config holds defaults read from the config file. Each field is named
+// after the flag it sets; pointers distinguish an unset number from zero.
+type config struct {
+	Provider   string   `json:"provider"`
+	Voice      string   `json:"voice"`
+	Model      string   `json:"model"`
+	Speed      *float64 `json:"speed"`
+	Stability  *float64 `json:"stability"`
+	Similarity *float64 `json:"similarity"`
+	Format     string   `json:"format"`
+	MaxChars   *int     `json:"max-chars"`
+	// Profiles are named groups of settings, picked with --profile
+	Profiles map[string]profile `json:"profiles"`
+}
+
+// profile is a named group of settings in the config file, loaded
+// together with --profile. Like config, each field is named after the
+// flag it sets.
+type profile struct {
+	Provider string   `json:"provider"`
+	Voice    string   `json:"voice"`
+	Model    string   `json:"model"`
+	Speed    *float64 `json:"speed"`
+	Format   string   `json:"format"`
+	Style    *float64 `json:"style"`
+}
+
+// profileKeys lists the settings a profile can provide, by flag name.
+var profileKeys = []string{"provider", "voice", "model", "speed", "format", "style"}
+
+// configKeys lists the settings a config file or GOSPEAK_* environment
+// variable can provide, by flag name.
+var configKeys = []string{"provider", "voice", "model", "speed", "stability", "similarity", "format", "max-chars"}
+
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gospeak", "config.json"), nil
+}
+
+// configPathFromArgs finds --config in the raw arguments, since the file
+// has to be read before flag.Parse.
+func configPathFromArgs(args []string) string {
+	return argValue(args, "config")
+}
+
+// profileFromArgs finds --profile in the raw arguments, for the same
+// reason, falling back to GOSPEAK_PROFILE.
+func profileFromArgs(args []string) string {
+	if name := argValue(args, "profile"); name != "" {
+		return name
+	}
+	return os.Getenv("GOSPEAK_PROFILE")
+}
+
+// argValue returns the value of the flag called name in args, given as
+// --name value or --name=value, or "" if it isn't there.
+func argValue(args []string, flagName string) string {
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != flagName {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// loadConfig reads the config file at path, or the default location if
+// path is empty. A missing default file is not an error.
+func loadConfig(path string) (*config, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return &config{}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var c config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *config) settings() map[string]string {
+	s := map[string]string{
+		"provider": c.Provider,
+		"voice":    c.Voice,
+		"model":    c.Model,
+		"format":   c.Format,
+	}
+	for name, v := range map[string]*float64{"speed": c.Speed, "stability": c.Stability, "similarity": c.Similarity} {
+		if v != nil {
+			s[name] = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+	if c.MaxChars != nil {
+		s["max-chars"] = strconv.Itoa(*c.MaxChars)
+	}
+	return s
+}
+
+func (p profile) settings() map[string]string {
+	s := map[string]string{
+		"provider": p.Provider,
+		"voice":    p.Voice,
+		"model":    p.Model,
+		"format":   p.Format,
+	}
+	for name, v := range map[string]*float64{"speed": p.Speed, "style": p.Style} {
+		if v != nil {
+			s[name] = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+	return s
+}
+
+// lookupProfile returns the profile called name from the config file.
+func (c *config) lookupProfile(name string) (profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return profile{}, fmt.Errorf("Unknown profile '%s': the config file defines no profiles", name)
+		}
+		return profile{}, fmt.Errorf("Unknown profile '%s'. Use %s", name, strings.Join(slices.Sorted(maps.Keys(c.Profiles)), ", "))
+	}
+	return p, nil
+}
+
+// providerSetting returns the default provider chosen by the profile
+// called profileName, GOSPEAK_PROVIDER, or the config file, in that
+// order, with where it came from. Both are empty if none chooses one.
+func providerSetting(c *config, profileName string) (provider, source string) {
+	if p, ok := c.Profiles[profileName]; ok && p.Provider != "" {
+		return p.Provider, "profile '" + profileName + "'"
+	}
+	if v := os.Getenv("GOSPEAK_PROVIDER"); v != "" {
+		return v, "GOSPEAK_PROVIDER"
+	}
+	if c.Provider != "" {
+		return c.Provider, "config"
+	}
+	return "", ""
+}
+
+// applyDefaults sets flag defaults from the config file, overridden by
+// GOSPEAK_* environment variables and then by the profile called
+// profileName, if any. It must run before flag.Parse so that flags given
+// on the command line still win. Values are set directly rather than
+// through flag.Set, so flagSet keeps reporting only flags the user typed.
+func applyDefaults(c *config, profileName string) error {
+	settings := c.settings()
+	for _, name := range configKeys {
+		value, source := settings[name], "config"
+		env := "GOSPEAK_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if v := os.Getenv(env); v != "" {
+			value, source = v, env
+		}
+		if value == "" {
+			continue
+		}
+		// Caught here even when -p overrides it, so a typo isn't
+		// silently ignored until the flag is dropped
+		if name == "provider" && !isValidProvider(strings.ToLower(value)) {
+			return fmt.Errorf("Invalid provider '%s' from %s. Use %s", value, source, providerChoices())
+		}
+		if err := flag.Lookup(name).Value.Set(value); err != nil {
+			return fmt.Errorf("invalid %s '%s' from %s", name, value, source)
+		}
+	}
+
+	if profileName == "" {
+		return nil
+	}
+	p, err := c.lookupProfile(profileName)
+	if err != nil {
+		return err
+	}
+	settings = p.settings()
+	for _, name := range profileKeys {
+		value := settings[name]
+		if value == "" {
+			continue
+		}
+		if name == "provider" && !isValidProvider(strings.ToLower(value)) {
+			return fmt.Errorf("Invalid provider '%s' in profile '%s'. Use %s", value, profileName, providerChoices())
+		}
+		if err := flag.Lookup(name).Value.Set(value); err != nil {
+			return fmt.Errorf("invalid %s '%s' in profile '%s'", name, value, profileName)
+		}
+	}
+	return nil
+}
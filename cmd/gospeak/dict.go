@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// A dictEntry is one line of a --dict file.
+type dictEntry struct {
+	pattern     *regexp.Regexp
+	replacement string
+	literal     bool // replacement is inserted as-is, without $1 expansion
+}
+
+// wordChar matches a character \b counts as part of a word.
+var wordChar = regexp.MustCompile(`^\w$`)
+
+// loadDict reads a pronunciation dictionary: one "pattern=replacement" per
+// line, such as "GIF=jiff". Blank lines and lines starting with # are
+// skipped. A plain pattern matches that text in any case, as a whole word
+// where it begins or ends with a letter or digit. A pattern written as
+// /regexp/ is used as a Go regular expression, and its replacement may
+// refer to groups as $1.
+func loadDict(path string) ([]dictEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []dictEntry
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, replacement, ok := strings.Cut(line, "=")
+		// A regexp may itself contain "=", so look for the one after it
+		if strings.HasPrefix(line, "/") {
+			if end := strings.Index(line[1:], "/="); end >= 0 {
+				pattern, replacement, ok = line[:end+2], line[end+3:], true
+			}
+		}
+		pattern, replacement = strings.TrimSpace(pattern), strings.TrimSpace(replacement)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%s:%d: expected pattern=replacement", path, n)
+		}
+
+		entry := dictEntry{replacement: replacement}
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			entry.pattern, err = regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+			}
+		} else {
+			expr := regexp.QuoteMeta(pattern)
+			if wordChar.MatchString(pattern[:1]) {
+				expr = `\b` + expr
+			}
+			if wordChar.MatchString(pattern[len(pattern)-1:]) {
+				expr += `\b`
+			}
+			entry.pattern = regexp.MustCompile("(?i)" + expr)
+			entry.literal = true
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// applyDict makes the dictionary's replacements in file order, so an
+// entry sees the text as the ones before it left it.
+func applyDict(text string, dict []dictEntry) string {
+	for _, e := range dict {
+		if e.literal {
+			text = e.pattern.ReplaceAllLiteralString(text, e.replacement)
+		} else {
+			text = e.pattern.ReplaceAllString(text, e.replacement)
+		}
+	}
+	return text
+}
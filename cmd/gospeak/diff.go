@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// addedLines returns the parts of newText that are not in oldText, i.e.
+// the additions. Lines are compared first, through their longest common
+// subsequence. Where lines were changed rather than only added, their
+// words are compared the same way, so a one-word edit speaks just that
+// word; each run of added words on a line is one entry. Blank lines are
+// dropped since there is nothing to speak.
+func addedLines(oldText, newText string) []string {
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+	ids := map[string]int{}
+	keptOld, keptNew := commonTokens(tokenIDs(ids, oldLines), tokenIDs(ids, newLines))
+
+	// Walk the two files in step: between each pair of common lines is a
+	// hunk of removed lines and a hunk of added ones
+	var added []string
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		oldStart, newStart := i, j
+		for i < len(oldLines) && !keptOld[i] {
+			i++
+		}
+		for j < len(newLines) && !keptNew[j] {
+			j++
+		}
+		if oldStart == i {
+			for _, line := range newLines[newStart:j] {
+				if line = strings.TrimSpace(line); line != "" {
+					added = append(added, line)
+				}
+			}
+		} else if newStart < j {
+			added = append(added, addedWords(oldLines[oldStart:i], newLines[newStart:j])...)
+		}
+		// Step over the common line
+		i++
+		j++
+	}
+	return added
+}
+
+// addedWords returns the runs of words in newLines that aren't in
+// oldLines, one entry per run, never spanning lines.
+func addedWords(oldLines, newLines []string) []string {
+	oldWords := strings.Fields(strings.Join(oldLines, " "))
+	var newWords []string
+	var lineOf []int
+	for n, line := range newLines {
+		for _, w := range strings.Fields(line) {
+			newWords = append(newWords, w)
+			lineOf = append(lineOf, n)
+		}
+	}
+	ids := map[string]int{}
+	_, kept := commonTokens(tokenIDs(ids, oldWords), tokenIDs(ids, newWords))
+
+	var added []string
+	var run []string
+	for k, w := range newWords {
+		if k > 0 && (kept[k] || lineOf[k] != lineOf[k-1]) && len(run) > 0 {
+			added = append(added, strings.Join(run, " "))
+			run = nil
+		}
+		if !kept[k] {
+			run = append(run, w)
+		}
+	}
+	if len(run) > 0 {
+		added = append(added, strings.Join(run, " "))
+	}
+	return added
+}
+
+// tokenIDs numbers tokens by content, sharing ids between calls, so they
+// can be compared as ints.
+func tokenIDs(ids map[string]int, tokens []string) []int {
+	out := make([]int, len(tokens))
+	for i, t := range tokens {
+		id, ok := ids[t]
+		if !ok {
+			id = len(ids)
+			ids[t] = id
+		}
+		out[i] = id
+	}
+	return out
+}
+
+// commonTokens reports which tokens of a and of b make up their longest
+// common subsequence.
+func commonTokens(a, b []int) (keptA, keptB []bool) {
+	keptA, keptB = make([]bool, len(a)), make([]bool, len(b))
+
+	// Tokens shared at the start and end are common to any LCS, and are
+	// usually most of the file
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		keptA[prefix], keptB[prefix] = true, true
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		keptA[len(a)-1-suffix], keptB[len(b)-1-suffix] = true, true
+		suffix++
+	}
+	markLCS(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], keptA[prefix:len(a)-suffix], keptB[prefix:len(b)-suffix])
+	return keptA, keptB
+}
+
+// markLCS sets keptA[i] and keptB[j] for each a[i] and b[j] paired in a
+// longest common subsequence of a and b. It is Hirschberg's algorithm, so
+// two long files take memory in proportion to their length rather than
+// to the product of their lengths.
+func markLCS(a, b []int, keptA, keptB []bool) {
+	switch len(a) {
+	case 0:
+		return
+	case 1:
+		for j, id := range b {
+			if id == a[0] {
+				keptA[0], keptB[j] = true, true
+				return
+			}
+		}
+		return
+	}
+
+	// Split a in half, and b where the LCS of the halves is longest
+	mid := len(a) / 2
+	front := lcsPrefixLengths(a[:mid], b)
+	back := lcsSuffixLengths(a[mid:], b)
+	split, best := 0, -1
+	for k := range len(b) + 1 {
+		if n := front[k] + back[k]; n > best {
+			split, best = k, n
+		}
+	}
+	markLCS(a[:mid], b[:split], keptA[:mid], keptB[:split])
+	markLCS(a[mid:], b[split:], keptA[mid:], keptB[split:])
+}
+
+// lcsPrefixLengths returns, for each k, the LCS length of a and b[:k].
+func lcsPrefixLengths(a, b []int) []int {
+	row := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	for _, x := range a {
+		row, prev = prev, row
+		for j, y := range b {
+			if x == y {
+				row[j+1] = prev[j] + 1
+			} else {
+				row[j+1] = max(prev[j+1], row[j])
+			}
+		}
+	}
+	return row
+}
+
+// lcsSuffixLengths returns, for each k, the LCS length of a and b[k:].
+func lcsSuffixLengths(a, b []int) []int {
+	row := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		row, prev = prev, row
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				row[j] = prev[j+1] + 1
+			} else {
+				row[j] = max(prev[j], row[j+1])
+			}
+		}
+	}
+	return row
+}
+
+// diffText reads two files and returns the text to speak for their diff.
+// With announce set, each addition is prefixed with "Added:".
+func diffText(oldPath, newPath string, announce bool) (string, error) {
+	oldData, err := os.ReadFile(oldPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", oldPath, err)
+	}
+	newData, err := os.ReadFile(newPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", newPath, err)
+	}
+
+	added := addedLines(string(oldData), string(newData))
+	if announce {
+		for i, line := range added {
+			added[i] = "Added: " + line
+		}
+	}
+	return strings.Join(added, "\n"), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/schappim/gospeak"
+)
+
+// errDryRun stops a request at the transport once it has been printed.
+var errDryRun = errors.New("dry run")
+
+// dryRunTransport prints each request instead of sending it.
+type dryRunTransport struct{}
+
+func (dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The only GET made while synthesizing is the ElevenLabs voice lookup
+	if req.Method == http.MethodGet {
+		fmt.Fprintln(os.Stderr, "The voice isn't a preset or ID, so a real run first looks it up in the account:")
+	}
+	printRequest(req)
+	return nil, errDryRun
+}
+
+// dryRun prints the requests synthesizing r would make, one per chunk of
+// long text, without sending any of them. For Piper, say, and espeak it
+// prints the command that would run instead. A callback URL shows
+// Deepgram's asynchronous requests.
+func dryRun(ctx context.Context, r speechRequest, callback string) error {
+	// Route every request to the printer; a retry would only print it
+	// again, and nothing is sent that needs pacing
+	httpClient = &http.Client{Transport: dryRunTransport{}}
+	maxRetries = 0
+	providerRPS = 0
+
+	chunks := []string{r.text}
+	if !r.ssml {
+		chunks = gospeak.SplitText(r.text, maxCharsFor(r.provider))
+	}
+	if len(chunks) > 1 {
+		fmt.Fprintf(os.Stderr, "Text is split into %d requests:\n\n", len(chunks))
+	}
+
+	for _, chunk := range chunks {
+		part := r
+		part.text = chunk
+		if !needsAPIKey(part.provider) {
+			var cmd *exec.Cmd
+			var err error
+			switch part.provider {
+			case "piper":
+				cmd, err = gospeak.PiperProvider{Bin: piperBin}.Command(ctx, part.libraryRequest())
+			case "say":
+				cmd, err = gospeak.SayProvider{}.Command(ctx, part.libraryRequest(), "speech.wav")
+			case "espeak":
+				cmd, err = gospeak.EspeakProvider{}.Command(ctx, part.libraryRequest())
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%s\nstdin: %s\n\n", strings.Join(cmd.Args, " "), chunk)
+			continue
+		}
+
+		p, err := newProvider(part)
+		if err != nil {
+			return err
+		}
+		if dg, ok := p.(gospeak.DeepgramProvider); ok && callback != "" {
+			_, err = dg.SubmitAsync(ctx, part.libraryRequest(), callback)
+		} else {
+			_, err = p.Synthesize(ctx, part.libraryRequest())
+		}
+		if err != nil && !errors.Is(err, errDryRun) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	tempFilesMu sync.Mutex
+	tempFiles   = map[string]struct{}{}
+)
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partially-written file. The
+// temporary file is removed on error, and by removeTempFiles if gospeak
+// exits while the write is in progress.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	trackTempFile(tmp)
+	defer untrackTempFile(tmp)
+
+	fail := func(err error) error {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := f.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+func trackTempFile(name string) {
+	tempFilesMu.Lock()
+	tempFiles[name] = struct{}{}
+	tempFilesMu.Unlock()
+}
+
+func untrackTempFile(name string) {
+	tempFilesMu.Lock()
+	delete(tempFiles, name)
+	tempFilesMu.Unlock()
+}
+
+// removeTempFiles deletes any temp files still being written. It is called
+// on the way out by exit and exitIfInterrupted, since Ctrl+C is handled by
+// interruptContext rather than by killing the process.
+func removeTempFiles() {
+	tempFilesMu.Lock()
+	defer tempFilesMu.Unlock()
+	for name := range tempFiles {
+		os.Remove(name)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Longest line --follow reads; longer ones are an error
+	maxFollowLine = 1 << 20
+	// Lines --follow synthesizes ahead of the one playing
+	followQueueDepth = 3
+)
+
+// runFollow speaks each line of piped input as soon as it is complete,
+// for --follow, so a live log can be narrated:
+//
+//	tail -f app.log | gospeak --follow
+//
+// prepare applies the same text rewriting as a normal run, and each line
+// goes through the cache and counts towards the monthly budget, as in the
+// REPL. Lines are synthesized while earlier ones play, so a burst of
+// lines is spoken without gaps. It returns once everything read before EOF
+// has been spoken; Ctrl+C stops it.
+func runFollow(base speechRequest, prepare func(string) (string, error), budget float64, useCache bool) {
+	usage := openUsage(budget)
+
+	ctx := interruptContext()
+	queue := newPlayQueue(ctx, followQueueDepth)
+
+	// Read in the background, since a read can't be interrupted: Ctrl+C
+	// while waiting for input must not wait for the next line
+	lines := make(chan string)
+	var readErr error
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), maxFollowLine)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr = scanner.Err()
+	}()
+
+read:
+	for {
+		var line string
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				break read
+			}
+			line = strings.TrimSpace(l)
+		case <-ctx.Done():
+			break read
+		}
+		if line == "" {
+			continue
+		}
+		text, err := prepare(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+
+		r := base
+		r.text = text
+		audioData := synthesizeLine(ctx, r, usage, budget, useCache)
+		if audioData != nil {
+			queue.add(audioData)
+		}
+	}
+	exitIfInterrupted(ctx, queue.wait())
+	exitIfInterrupted(ctx, ctx.Err())
+	if readErr != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", readErr)
+		exit(1)
+	}
+}
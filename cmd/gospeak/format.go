@@ -0,0 +1,18 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/schappim/gospeak"
+)
+
+// extensionFormat returns the audio format implied by path's extension,
+// or "" if the extension isn't one of gospeak.AudioFormats.
+func extensionFormat(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if gospeak.IsValidFormat(ext) {
+		return ext
+	}
+	return ""
+}
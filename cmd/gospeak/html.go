@@ -0,0 +1,33 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlHidden = regexp.MustCompile(`(?is)<(script|style|head|template)\b.*?</(script|style|head|template)\s*>|<!--.*?-->`)
+	htmlBreak  = regexp.MustCompile(`(?i)<(br|/?(p|div|li|ul|ol|tr|h[1-6]|blockquote|pre|section|article|header|footer|table))\b[^>]*>`)
+	htmlTag    = regexp.MustCompile(`<[^>]*>`)
+	blankLines = regexp.MustCompile(`\n\s*\n\s*`)
+	spaceRuns  = regexp.MustCompile(`[ \t]+`)
+)
+
+// stripHTML removes HTML tags for --strip-html, keeping the text they
+// contain: link text stays while its URL goes. Scripts, styles, and
+// comments are dropped entirely, block elements become line breaks so
+// the voice pauses between them, and entities are decoded.
+func stripHTML(doc string) string {
+	doc = htmlHidden.ReplaceAllString(doc, "")
+	doc = htmlBreak.ReplaceAllString(doc, "\n")
+	doc = htmlTag.ReplaceAllString(doc, "")
+	doc = html.UnescapeString(doc)
+
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRuns.ReplaceAllString(line, " "))
+	}
+	doc = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(doc, "\n\n"))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Headers and query parameters that carry credentials.
+var (
+	secretHeaders = []string{"Authorization", "Xi-Api-Key", "X-Amz-Security-Token", "Ocp-Apim-Subscription-Key"}
+	secretParams  = []string{"key"}
+)
+
+// verboseTransport logs each request and its response to stderr for
+// --verbose. The audio in a successful response isn't shown, but the body
+// of an error response is, in full.
+type verboseTransport struct {
+	base http.RoundTripper
+}
+
+func (t verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clearProgress()
+	printRequest(req)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Request failed: %v\n\n", err)
+		return nil, err
+	}
+	printResponse(resp)
+	return resp, nil
+}
+
+// printRequest writes req to stderr with its credentials redacted and a
+// JSON body indented. The body is put back for sending.
+func printRequest(req *http.Request) {
+	u := *req.URL
+	q := u.Query()
+	for _, p := range secretParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+			u.RawQuery = q.Encode()
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%s %s\n", req.Method, u.String())
+	printHeaders(req.Header)
+
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		printBody(body)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// printResponse writes the status and headers of resp to stderr, and
+// for an error status its body too, which is put back for the caller.
+func printResponse(resp *http.Response) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", resp.Proto, resp.Status)
+	printHeaders(resp.Header)
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		printBody(body)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// printHeaders writes h sorted by name, masking credentials but keeping
+// an Authorization scheme such as Bearer.
+func printHeaders(h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value := strings.Join(h.Values(name), ", ")
+		if slices.Contains(secretHeaders, http.CanonicalHeaderKey(name)) {
+			scheme, _, found := strings.Cut(value, " ")
+			value = "REDACTED"
+			if found && name == "Authorization" {
+				value = scheme + " REDACTED"
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, value)
+	}
+}
+
+// printBody writes a request or response body after a blank line,
+// indenting it if it is JSON.
+func printBody(body []byte) {
+	var indented bytes.Buffer
+	if json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	fmt.Fprintf(os.Stderr, "\n%s\n", bytes.TrimRight(body, "\n"))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/schappim/gospeak"
+)
+
+// Names of the languages detectLanguage can report
+var languageNames = map[string]string{
+	"en": "English", "de": "German", "fr": "French", "es": "Spanish",
+	"it": "Italian", "pt": "Portuguese", "nl": "Dutch", "ru": "Russian",
+	"uk": "Ukrainian", "el": "Greek", "ar": "Arabic", "he": "Hebrew",
+	"hi": "Hindi", "th": "Thai", "ja": "Japanese", "ko": "Korean",
+	"zh": "Chinese",
+}
+
+// Common short words that tell the Latin-script languages apart
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "in", "that", "it", "with", "for", "this", "are"},
+	"de": {"der", "die", "und", "ist", "nicht", "das", "mit", "ich", "ein", "eine", "sie", "auf"},
+	"fr": {"le", "la", "les", "et", "est", "une", "des", "pas", "que", "pour", "dans", "avec"},
+	"es": {"el", "los", "las", "y", "es", "una", "del", "que", "por", "para", "con", "pero"},
+	"it": {"il", "gli", "e", "è", "una", "che", "non", "per", "con", "sono", "della", "questo"},
+	"pt": {"os", "as", "e", "é", "um", "uma", "que", "não", "para", "com", "do", "da"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "van", "dat", "met", "voor", "zijn", "ik"},
+}
+
+// Voices to switch to for a detected language, by provider. Only used
+// when the voice is still the provider's default.
+var languageVoices = map[string]map[string]string{
+	"google": {
+		"de": "de-DE-Standard-A", "fr": "fr-FR-Standard-A", "es": "es-ES-Standard-A",
+		"it": "it-IT-Standard-A", "pt": "pt-BR-Standard-A", "nl": "nl-NL-Standard-A",
+		"ru": "ru-RU-Standard-A", "uk": "uk-UA-Standard-A", "el": "el-GR-Standard-A",
+		"ar": "ar-XA-Standard-A", "he": "he-IL-Standard-A", "hi": "hi-IN-Standard-A",
+		"th": "th-TH-Standard-A", "ja": "ja-JP-Standard-A", "ko": "ko-KR-Standard-A",
+		"zh": "cmn-CN-Standard-A",
+	},
+	"azure": {
+		"de": "de-DE-KatjaNeural", "fr": "fr-FR-DeniseNeural", "es": "es-ES-ElviraNeural",
+		"it": "it-IT-ElsaNeural", "pt": "pt-BR-FranciscaNeural", "nl": "nl-NL-ColetteNeural",
+		"ru": "ru-RU-SvetlanaNeural", "uk": "uk-UA-PolinaNeural", "el": "el-GR-AthinaNeural",
+		"ar": "ar-SA-ZariyahNeural", "he": "he-IL-HilaNeural", "hi": "hi-IN-SwaraNeural",
+		"th": "th-TH-PremwadeeNeural", "ja": "ja-JP-NanamiNeural", "ko": "ko-KR-SunHiNeural",
+		"zh": "zh-CN-XiaoxiaoNeural",
+	},
+	"polly": {
+		"de": "Vicki", "fr": "Lea", "es": "Lucia", "it": "Bianca", "pt": "Camila",
+		"nl": "Laura", "ar": "Hala", "hi": "Kajal", "ja": "Takumi", "ko": "Seoyeon",
+		"zh": "Zhiyu",
+	},
+	"say": {
+		"de": "Anna", "fr": "Thomas", "es": "Monica", "it": "Alice", "pt": "Luciana",
+		"nl": "Xander", "ru": "Milena", "el": "Melina", "ar": "Maged", "he": "Carmit",
+		"hi": "Lekha", "th": "Kanya", "ja": "Kyoko", "ko": "Yuna", "zh": "Ting-Ting",
+	},
+	// espeak-ng voices are named after their language
+	"espeak": {
+		"de": "de", "fr": "fr", "es": "es", "it": "it", "pt": "pt-BR", "nl": "nl",
+		"ru": "ru", "uk": "uk", "el": "el", "ar": "ar", "he": "he", "hi": "hi",
+		"th": "th", "ja": "ja", "ko": "ko", "zh": "cmn",
+	},
+}
+
+// detectLanguage guesses the language of text from its script, and for
+// Latin script from common short words. It returns "" when it can't
+// tell, and only guesses a Latin-script language other than English when
+// that language clearly wins.
+func detectLanguage(text string) string {
+	var latin, kana, total int
+	scripts := map[string]int{}
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				scripts["uk"]++
+			}
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	// Japanese mixes kanji with kana, so any kana means Japanese
+	if kana > 0 {
+		return "ja"
+	}
+	if latin*2 < total {
+		lang, most := "", 0
+		for l, n := range scripts {
+			if l != "uk" && n > most {
+				lang, most = l, n
+			}
+		}
+		if lang == "ru" && scripts["uk"] > 0 {
+			return "uk"
+		}
+		return lang
+	}
+
+	hits := map[string]int{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for lang, words := range stopWords {
+			if slices.Contains(words, w) {
+				hits[lang]++
+			}
+		}
+	}
+	best := ""
+	for lang, n := range hits {
+		if best == "" || n > hits[best] || (n == hits[best] && lang < best) {
+			best = lang
+		}
+	}
+	second := 0
+	for lang, n := range hits {
+		if lang != best {
+			second = max(second, n)
+		}
+	}
+	if best == "" || (best != "en" && (hits[best] < 3 || hits[best] < 2*second)) {
+		return ""
+	}
+	return best
+}
+
+// speaksEnglishOnly reports whether voice, with model, can only speak
+// English.
+func speaksEnglishOnly(provider, voice, model string) bool {
+	switch provider {
+	case "deepgram":
+		if v, ok := gospeak.DeepgramVoices[strings.ToLower(voice)]; ok {
+			voice = v
+		}
+		return strings.HasSuffix(voice, "-en")
+	case "elevenlabs":
+		return model == "eleven_monolingual_v1" || model == "eleven_turbo_v2" || model == "eleven_flash_v2"
+	case "google":
+		if v, ok := gospeak.GoogleVoices[strings.ToLower(voice)]; ok {
+			voice = v
+		}
+		return strings.HasPrefix(voice, "en-")
+	case "azure":
+		if v, ok := gospeak.AzureVoices[strings.ToLower(voice)]; ok {
+			voice = v
+		}
+		return strings.HasPrefix(voice, "en-")
+	case "watson":
+		if v, ok := gospeak.WatsonVoices[strings.ToLower(voice)]; ok {
+			voice = v
+		}
+		return strings.HasPrefix(voice, "en-")
+	case "polly":
+		// The presets are all English voices
+		_, ok := gospeak.PollyVoices[strings.ToLower(voice)]
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// numberFormat describes how a locale writes and speaks decimal numbers.
+type numberFormat struct {
+	group       string // thousands separator
+	decimal     string // decimal separator
+	decimalWord string // spoken decimal separator
+}
+
+// Number formats by language, selected by the language part of --locale.
+var localeNumberFormats = map[string]numberFormat{
+	"en": {group: ",", decimal: ".", decimalWord: "point"},
+	"de": {group: ".", decimal: ",", decimalWord: "Komma"},
+	"nl": {group: ".", decimal: ",", decimalWord: "komma"},
+	"es": {group: ".", decimal: ",", decimalWord: "coma"},
+	"it": {group: ".", decimal: ",", decimalWord: "virgola"},
+	"pt": {group: ".", decimal: ",", decimalWord: "vírgula"},
+	"fr": {group: " ", decimal: ",", decimalWord: "virgule"},
+}
+
+// lookupNumberFormat returns the number format for a locale such as
+// "de-DE" or "en_US".
+func lookupNumberFormat(locale string) (numberFormat, error) {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	nf, ok := localeNumberFormats[lang]
+	if !ok {
+		return numberFormat{}, fmt.Errorf("unsupported locale '%s'", locale)
+	}
+	return nf, nil
+}
+
+// normalizeNumbers rewrites numbers written in the locale's format into an
+// unambiguous spoken form: grouping separators are dropped and decimals are
+// read digit by digit, so "1.000,50" in de-DE becomes "1000 Komma 5 0".
+func normalizeNumbers(text string, nf numberFormat) string {
+	g := regexp.QuoteMeta(nf.group)
+	d := regexp.QuoteMeta(nf.decimal)
+	re := regexp.MustCompile(`\b\d{1,3}(?:` + g + `\d{3})+(?:` + d + `\d+)?\b|\b\d+` + d + `\d+\b`)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		// Leave things like dates and version numbers ("1.2.3") alone
+		if touchesNumber(text, start, end, nf) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(speakNumber(text[start:end], nf))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// touchesNumber reports whether the match at text[start:end] continues into
+// more digits via a separator on either side.
+func touchesNumber(text string, start, end int, nf numberFormat) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+	for _, sep := range []string{nf.group, nf.decimal} {
+		if strings.HasSuffix(text[:start], sep) && isDigit(start-len(sep)-1) {
+			return true
+		}
+		if strings.HasPrefix(text[end:], sep) && isDigit(end+len(sep)) {
+			return true
+		}
+	}
+	return false
+}
+
+func speakNumber(num string, nf numberFormat) string {
+	intPart, frac, hasFrac := strings.Cut(num, nf.decimal)
+	intPart = strings.ReplaceAll(intPart, nf.group, "")
+	if !hasFrac {
+		return intPart
+	}
+	digits := strings.Split(frac, "")
+	return intPart + " " + nf.decimalWord + " " + strings.Join(digits, " ")
+}
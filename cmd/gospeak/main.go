@@ -0,0 +1,1938 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/schappim/gospeak"
+)
+
+const (
+	defaultSpeed    = gospeak.DefaultSpeed
+	defaultProvider = "openai"
+	// retriesLimit bounds --retries; past it a provider is down, not busy
+	retriesLimit = 100
+)
+
+func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		if err := runCacheCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "voices" {
+		if err := runVoicesCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "providers" {
+		if err := runProvidersCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServeCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	var (
+		provider          string
+		voice             string
+		model             string
+		output            string
+		format            string
+		speed             float64
+		wpm               int
+		abSpecs           stringList
+		speak             bool
+		noPlay            bool
+		token             string
+		tokenFile         string
+		help              bool
+		allFlag           bool
+		allGap            time.Duration
+		announce          bool
+		voicesFlag        string
+		stability         float64
+		similarityBoost   float64
+		budgetFlag        string
+		showCost          bool
+		estimateFlag      bool
+		yes               bool
+		timestampsPath    string
+		alignPath         string
+		srtPath           string
+		streamInput       bool
+		callbackURL       string
+		maxChars          int
+		showDuration      bool
+		diffPath          string
+		diffAnnounce      bool
+		locale            string
+		outputTemplate    string
+		noClobber         bool
+		piperBinFlag      string
+		resembleFlag      string
+		providersPriority string
+		capsAs            string
+		dictPath          string
+		detectLang        bool
+		allProviders      bool
+		maxConcurrent     int
+		mdStructure       bool
+		stripMD           bool
+		stripHTMLFlag     bool
+		ssml              bool
+		configPath        string
+		profileName       string
+		noCache           bool
+		preview           previewCount
+		clearCache        bool
+		mdCode            string
+		statsdAddr        string
+		playbackRate      string
+		volume            float64
+		playCommand       string
+		normalize         bool
+		trimSilence       bool
+		repeat            int
+		repeatGap         time.Duration
+		repl              bool
+		follow            bool
+		dryRunFlag        bool
+		verbose           bool
+		timeout           time.Duration
+		listVoicesFlag    bool
+		listProvidersFlag bool
+		baseURLFlag       string
+		proxyFlag         string
+		instructions      string
+		seedFlag          uint64
+		tagTitle          string
+		tagArtist         string
+		style             float64
+		speakerBoostFlag  bool
+		sampleRate        int
+		bitrate           int
+		language          string
+		inputFile         string
+		batch             bool
+		outputDir         string
+		concurrency       int
+		voicesJSON        bool
+	)
+
+	flag.StringVar(&provider, "provider", defaultProvider, "TTS provider (openai, elevenlabs, deepgram, google, polly, azure, playht, watson, lmnt, resemble, piper, say, espeak)")
+	flag.StringVar(&provider, "p", defaultProvider, "TTS provider (shorthand)")
+	flag.StringVar(&voice, "voice", "", "Voice to use (see --help for options)")
+	flag.StringVar(&voice, "v", "", "Voice to use (shorthand)")
+	flag.StringVar(&model, "model", "", "Model to use")
+	flag.StringVar(&model, "m", "", "Model to use (shorthand)")
+	flag.StringVar(&model, "engine", "", "Polly engine: neural, standard (alias for --model)")
+	flag.StringVar(&output, "output", "", "Save audio to this file, or - for stdout")
+	flag.StringVar(&tagTitle, "tag-title", "", "ID3 title for a saved MP3 (default: the start of the text)")
+	flag.StringVar(&tagArtist, "tag-artist", "", "ID3 artist for a saved MP3 (default: the provider)")
+	flag.StringVar(&output, "o", "", "Save audio to this file, or - for stdout (shorthand)")
+	flag.StringVar(&inputFile, "file", "", "Read the text to speak from this file")
+	flag.StringVar(&inputFile, "f", "", "Read the text to speak from this file (shorthand)")
+	flag.BoolVar(&batch, "batch", false, "Synthesize each line of --file into its own numbered file in --output-dir")
+	flag.StringVar(&outputDir, "output-dir", "", "Directory for --batch, --all, or --voices output files")
+	flag.IntVar(&concurrency, "concurrency", 3, "Requests synthesizing at once in --batch, --all, and --ab mode")
+	flag.StringVar(&format, "format", "", "Audio format: mp3, wav, opus, flac, pcm (default: provider's native format)")
+	flag.Float64Var(&speed, "speed", defaultSpeed, "Speed of the voice")
+	flag.Float64Var(&speed, "x", defaultSpeed, "Speed of the voice (shorthand)")
+	flag.IntVar(&wpm, "wpm", 0, "Set the speed to read at about this many words per minute")
+	flag.Var(&abSpecs, "ab", "Compare setups given as provider:voice, played in turn after their letter (repeatable)")
+	flag.Var(&preview, "preview", "Speak only the first N words as a quick sample, 20 when given without N")
+	flag.BoolVar(&speak, "speak", false, "Speak the text even when saving to a file")
+	flag.BoolVar(&speak, "s", false, "Speak the text (shorthand)")
+	flag.BoolVar(&noPlay, "no-play", false, "Never play the audio, even with --speak")
+	flag.BoolVar(&quiet, "quiet", false, "Don't show progress while synthesizing")
+	flag.BoolVar(&quiet, "q", false, "Don't show progress (shorthand)")
+	flag.StringVar(&token, "token", "", "API key for the provider")
+	flag.StringVar(&tokenFile, "token-file", "", "Read the API key from a file, or keychain:service/account on macOS")
+	flag.BoolVar(&help, "help", false, "Show help")
+	flag.BoolVar(&help, "h", false, "Show help (shorthand)")
+	flag.BoolVar(&allFlag, "all", false, "Use all of the provider's preset voices")
+	flag.DurationVar(&allGap, "all-gap", time.Second, "Pause between voices with --all, --voices, or --ab")
+	flag.BoolVar(&announce, "announce", false, "Have each voice say its name first with --all or --voices")
+	flag.StringVar(&voicesFlag, "voices", "", "Comma-separated voices to speak with in turn, e.g. alloy,nova,shimmer")
+	flag.StringVar(&instructions, "instructions", "", "How the voice should speak, e.g. \"cheerfully and slowly\" (OpenAI gpt-4o-mini-tts only)")
+	flag.Uint64Var(&seedFlag, "seed", 0, "Seed for reproducible output (ElevenLabs and Play.ht only)")
+	flag.Float64Var(&stability, "stability", 0.5, "Voice stability (ElevenLabs only, 0.0-1.0)")
+	flag.Float64Var(&similarityBoost, "similarity", 0.75, "Similarity boost (ElevenLabs only, 0.0-1.0)")
+	flag.Float64Var(&style, "style", 0, "Style exaggeration (ElevenLabs only, 0.0-1.0)")
+	flag.BoolVar(&speakerBoostFlag, "speaker-boost", false, "Turn speaker boost on, or off with --speaker-boost=false (ElevenLabs only)")
+	flag.BoolVar(&beepOnError, "beep-on-error", false, "Play a short error tone on failure")
+	flag.StringVar(&budgetFlag, "budget", "", "Monthly spending limit, e.g. '$10/month'")
+	flag.IntVar(&maxChars, "max-chars", 0, "Refuse text longer than this many characters (0 for no limit)")
+	flag.BoolVar(&showCost, "show-cost", false, "Print estimated cost and remaining budget")
+	flag.BoolVar(&estimateFlag, "estimate", false, "Show the character count and estimated cost, and ask before synthesizing")
+	flag.BoolVar(&yes, "yes", false, "Don't ask for confirmation")
+	flag.BoolVar(&yes, "y", false, "Don't ask for confirmation (shorthand)")
+	flag.StringVar(&alignPath, "timestamps", "", "Write word and character timestamps from ElevenLabs (JSON) to this file")
+	flag.StringVar(&timestampsPath, "timestamps-estimate", "", "Write estimated word timestamps (JSON) to this file")
+	flag.StringVar(&srtPath, "srt", "", "Write sentence subtitles (SRT) to this file")
+	flag.BoolVar(&streamInput, "stream", false, "Stream text to ElevenLabs over a WebSocket for faster first audio")
+	flag.StringVar(&callbackURL, "callback", "", "Have Deepgram synthesize asynchronously and POST the audio to this URL")
+	flag.BoolVar(&showDuration, "duration", false, "Print the length of the audio")
+	flag.StringVar(&playbackRate, "playback-rate", "", "Resample playback to this sample rate in Hz, or auto for a standard rate")
+	flag.Float64Var(&volume, "volume", 1.0, "Playback volume, 0.0-1.0")
+	flag.BoolVar(&normalize, "normalize", false, "Bring each clip to the same loudness, for playback and saved WAV")
+	flag.Float64Var(&normalizeLevel, "normalize-level", gospeak.DefaultNormalizeLevel, "RMS level in dBFS for --normalize")
+	flag.BoolVar(&trimSilence, "trim-silence", false, "Trim silence from the start and end of each clip, for playback and saved WAV")
+	flag.Float64Var(&silenceThreshold, "silence-threshold", gospeak.DefaultSilenceThreshold, "Level in dBFS below which --trim-silence treats audio as silent")
+	flag.Float64Var(&pitch, "pitch", 0, "Shift the pitch by this many semitones, for playback and saved WAV")
+	flag.StringVar(&playCommand, "play-command", "", "Play audio by piping it to this command, e.g. \"ffplay -nodisp -autoexit -\"")
+	flag.IntVar(&repeat, "repeat", 1, "Play the audio this many times")
+	flag.DurationVar(&repeatGap, "repeat-gap", time.Second, "Pause between repeats")
+	flag.BoolVar(&repl, "repl", false, "Speak each line typed at a prompt until EOF or :quit")
+	flag.BoolVar(&follow, "follow", false, "Speak each line of piped input as it arrives, e.g. from tail -f")
+	flag.BoolVar(&dryRunFlag, "dry-run", false, "Print the API request that would be sent, without sending it")
+	flag.BoolVar(&verbose, "verbose", false, "Log each API request and response to stderr")
+	flag.BoolVar(&verbose, "V", false, "Log each API request and response (shorthand)")
+	flag.StringVar(&piperBinFlag, "piper-bin", "", "Path to the piper executable (default: $PIPER_BIN or piper)")
+	flag.StringVar(&resembleFlag, "resemble-project", "", "Resemble project UUID to save clips to (default: $RESEMBLE_PROJECT)")
+	flag.IntVar(&sampleRate, "sample-rate", 0, "Output sample rate in Hz (ElevenLabs, Deepgram wav, pcm, and flac, and LMNT only)")
+	flag.IntVar(&bitrate, "bitrate", 0, "Output bitrate in kbps (ElevenLabs mp3 and opus only)")
+	flag.StringVar(&language, "lang", "", "Language to speak in, as an ISO 639-1 code such as pt (ElevenLabs only)")
+	flag.StringVar(&proxyFlag, "proxy", "", "Send API requests through this http, https, or socks5 proxy URL")
+	flag.StringVar(&baseURLFlag, "base-url", "", "OpenAI-compatible API root or speech endpoint (default: $OPENAI_BASE_URL or OpenAI)")
+	flag.StringVar(&diffPath, "diff", "", "Speak only the lines added since this file (new file as argument)")
+	flag.BoolVar(&diffAnnounce, "diff-announce", false, "Prefix each added line with \"Added:\" in --diff mode")
+	flag.StringVar(&locale, "locale", "", "Read numbers using this locale's format (e.g. de-DE, en-US)")
+	flag.StringVar(&outputTemplate, "output-template", "", "Output filename template, e.g. 'clips/{{.Hash}}.mp3'")
+	flag.BoolVar(&noClobber, "no-clobber", false, "Skip synthesis if the output file already exists")
+	flag.StringVar(&providersPriority, "providers-priority", "", "Preferred provider order for auto-selection and fallback, e.g. elevenlabs,openai")
+	flag.StringVar(&capsAs, "caps-as", "normal", "How to read ALL-CAPS words: normal, emphasis, spell")
+	flag.BoolVar(&detectLang, "detect-lang", false, "Guess the text's language and pick a default voice that speaks it")
+	flag.StringVar(&dictPath, "dict", "", "Pronunciation dictionary: pattern=replacement per line, e.g. GIF=jiff")
+	flag.BoolVar(&allProviders, "all-providers", false, "Speak with every provider that has an API key")
+	flag.IntVar(&maxConcurrent, "max-concurrent-providers", 2, "Providers synthesizing at once in --all-providers mode")
+	flag.BoolVar(&mdStructure, "markdown-structure", false, "Narrate Markdown input: announce headings, pause between list items")
+	flag.BoolVar(&ssml, "ssml", false, "Treat the input text as SSML (ElevenLabs, Google, Polly, Azure, Watson, Resemble, espeak)")
+	flag.StringVar(&configPath, "config", "", "Read defaults from this config file")
+	flag.StringVar(&profileName, "profile", "", "Load a named group of settings from the config file")
+	flag.BoolVar(&noCache, "no-cache", false, "Don't read or write the audio cache")
+	flag.BoolVar(&clearCache, "clear-cache", false, "Remove all cached audio and exit")
+	flag.BoolVar(&listVoicesFlag, "list-voices", false, "List the provider's voices, including custom ElevenLabs voices, and exit")
+	flag.BoolVar(&listProvidersFlag, "list-providers", false, "List the providers, whether each is ready to use, and exit")
+	flag.BoolVar(&voicesJSON, "json", false, "Print --list-voices or --list-providers output as JSON")
+	flag.IntVar(&maxRetries, "retries", 3, "Retries on rate limiting and server errors")
+	flag.DurationVar(&retryMaxWait, "retry-max-wait", 30*time.Second, "Longest wait between retries")
+	flag.Float64Var(&providerRPS, "rps", defaultProviderRPS, "Most API requests per second to each provider, 0 for no limit")
+	flag.DurationVar(&timeout, "timeout", 60*time.Second, "Time limit for each API request attempt")
+	flag.StringVar(&mdCode, "markdown-code", "skip", "Code blocks in --markdown-structure mode: skip, read")
+	flag.BoolVar(&stripMD, "strip-markdown", false, "Remove Markdown syntax from the text before speaking it")
+	flag.BoolVar(&stripHTMLFlag, "strip-html", false, "Remove HTML tags from the text before speaking it")
+	flag.StringVar(&statsdAddr, "statsd", "", "Push request metrics to this StatsD address (host:port)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "gospeak - Text-to-speech using OpenAI, ElevenLabs, Deepgram, Google Cloud, AWS Polly, Azure, Play.ht, IBM Watson, LMNT, Resemble AI, local Piper, macOS say, or espeak-ng\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: gospeak [options] [text]\n")
+		fmt.Fprintf(os.Stderr, "       echo 'text' | gospeak [options]\n")
+		fmt.Fprintf(os.Stderr, "       gospeak cache <stats|prune|clear> [options]\n")
+		fmt.Fprintf(os.Stderr, "       gospeak voices [-p provider] [--json]\n")
+		fmt.Fprintf(os.Stderr, "       gospeak providers [--json]\n")
+		fmt.Fprintf(os.Stderr, "       gospeak serve [--addr :8080]\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fmt.Fprintf(os.Stderr, "  -p, --provider    TTS provider: openai, elevenlabs, deepgram, google, polly, azure, playht, watson, lmnt, resemble, piper, say, espeak (default: openai, or $GOSPEAK_PROVIDER)\n")
+		fmt.Fprintf(os.Stderr, "  -v, --voice       Voice to use (see below for options), or several separated by commas\n")
+		fmt.Fprintf(os.Stderr, "  -m, --model       Model to use\n")
+		fmt.Fprintf(os.Stderr, "      --engine      Polly engine: neural, standard (alias for --model)\n")
+		fmt.Fprintf(os.Stderr, "  -o, --output      Save audio to this file, or - to write it to stdout\n")
+		fmt.Fprintf(os.Stderr, "      --tag-title   ID3 title for a saved MP3 (default: the first 30 characters of the text)\n")
+		fmt.Fprintf(os.Stderr, "      --tag-artist  ID3 artist for a saved MP3 (default: the provider)\n")
+		fmt.Fprintf(os.Stderr, "  -f, --file        Read the text to speak from this file\n")
+		fmt.Fprintf(os.Stderr, "      --batch       Synthesize each line of --file into its own numbered file in --output-dir\n")
+		fmt.Fprintf(os.Stderr, "      --output-dir  Directory for --batch, --all, or --voices output files\n")
+		fmt.Fprintf(os.Stderr, "      --concurrency  Requests synthesizing at once in --batch, --all, and --ab mode (default: 3)\n")
+		fmt.Fprintf(os.Stderr, "      --format      Audio format: mp3, wav, opus, flac, pcm (default: mp3, or wav for Piper, say, and espeak)\n")
+		fmt.Fprintf(os.Stderr, "  -x, --speed       Speed of the voice (default: 1.0)\n")
+		fmt.Fprintf(os.Stderr, "      --wpm         Set the speed to read at about this many words per minute, e.g. 160\n")
+		fmt.Fprintf(os.Stderr, "  -s, --speak       Speak the text even when saving to a file\n")
+		fmt.Fprintf(os.Stderr, "      --no-play     Never play the audio, even with --speak (e.g. to warm the cache)\n")
+		fmt.Fprintf(os.Stderr, "  -q, --quiet       Don't show progress while synthesizing\n")
+		fmt.Fprintf(os.Stderr, "      --token       API key (or set env var)\n")
+		fmt.Fprintf(os.Stderr, "      --token-file  Read the API key from a file, or keychain:service/account on macOS\n")
+		fmt.Fprintf(os.Stderr, "      --all         Speak with all of the provider's preset voices\n")
+		fmt.Fprintf(os.Stderr, "      --voices      Comma-separated voices to speak with in turn, e.g. alloy,nova,shimmer\n")
+		fmt.Fprintf(os.Stderr, "      --ab          Compare a provider:voice setup; give it twice or more (e.g. --ab openai:nova --ab elevenlabs:rachel)\n")
+		fmt.Fprintf(os.Stderr, "      --all-gap     Pause between voices with --all, --voices, or --ab (default: 1s)\n")
+		fmt.Fprintf(os.Stderr, "      --preview     Speak only the first N words as a sample (default: 20), e.g. --preview 50; not cached\n")
+		fmt.Fprintf(os.Stderr, "      --announce    Have each voice say its name first with --all or --voices\n")
+		fmt.Fprintf(os.Stderr, "      --instructions  How the voice should speak (OpenAI gpt-4o-mini-tts only)\n")
+		fmt.Fprintf(os.Stderr, "      --seed        Seed for reproducible output (ElevenLabs and Play.ht only)\n")
+		fmt.Fprintf(os.Stderr, "      --stability   Voice stability, 0.0-1.0 (ElevenLabs only)\n")
+		fmt.Fprintf(os.Stderr, "      --similarity  Similarity boost, 0.0-1.0 (ElevenLabs only)\n")
+		fmt.Fprintf(os.Stderr, "      --style       Style exaggeration, 0.0-1.0 (ElevenLabs only)\n")
+		fmt.Fprintf(os.Stderr, "      --speaker-boost  Turn speaker boost on (or off with =false) (ElevenLabs only)\n")
+		fmt.Fprintf(os.Stderr, "      --beep-on-error  Play a short error tone on failure\n")
+		fmt.Fprintf(os.Stderr, "      --budget      Monthly spending limit, e.g. '$10/month'\n")
+		fmt.Fprintf(os.Stderr, "      --max-chars   Refuse text longer than this many characters (default: no limit)\n")
+		fmt.Fprintf(os.Stderr, "      --show-cost   Print estimated cost and remaining budget\n")
+		fmt.Fprintf(os.Stderr, "      --estimate    Show the character count and estimated cost, and ask before synthesizing\n")
+		fmt.Fprintf(os.Stderr, "  -y, --yes         Don't ask for confirmation with --estimate\n")
+		fmt.Fprintf(os.Stderr, "      --timestamps  Write word and character timestamps from ElevenLabs (JSON) to this file\n")
+		fmt.Fprintf(os.Stderr, "      --timestamps-estimate  Write estimated word timestamps (JSON) to this file\n")
+		fmt.Fprintf(os.Stderr, "      --srt         Write sentence subtitles (SRT) to this file\n")
+		fmt.Fprintf(os.Stderr, "      --stream      Stream text to ElevenLabs over a WebSocket for faster first audio\n")
+		fmt.Fprintf(os.Stderr, "      --duration    Print the length of the audio\n")
+		fmt.Fprintf(os.Stderr, "      --playback-rate  Resample playback to this sample rate, e.g. 48000, or auto for a standard rate\n")
+		fmt.Fprintf(os.Stderr, "      --volume      Playback volume, 0.0-1.0 (default: 1.0)\n")
+		fmt.Fprintf(os.Stderr, "      --normalize   Bring each clip to the same loudness, for playback and saved WAV\n")
+		fmt.Fprintf(os.Stderr, "      --normalize-level  RMS level in dBFS for --normalize (default: -20)\n")
+		fmt.Fprintf(os.Stderr, "      --trim-silence  Trim silence from the start and end of each clip, for playback and saved WAV\n")
+		fmt.Fprintf(os.Stderr, "      --silence-threshold  Level in dBFS below which audio counts as silent (default: -50)\n")
+		fmt.Fprintf(os.Stderr, "      --pitch       Shift the pitch by this many semitones, e.g. -3 or 2.5, for playback and saved WAV\n")
+		fmt.Fprintf(os.Stderr, "      --play-command  Play audio by piping it to this command, e.g. \"ffplay -nodisp -autoexit -\"\n")
+		fmt.Fprintf(os.Stderr, "      --repeat      Play the audio this many times (default: 1)\n")
+		fmt.Fprintf(os.Stderr, "      --repeat-gap  Pause between repeats (default: 1s)\n")
+		fmt.Fprintf(os.Stderr, "      --repl        Speak each line typed at a prompt until EOF or :quit\n")
+		fmt.Fprintf(os.Stderr, "      --follow      Speak each line of piped input as it arrives, e.g. from tail -f\n")
+		fmt.Fprintf(os.Stderr, "      --dry-run     Print the API request that would be sent, without sending it\n")
+		fmt.Fprintf(os.Stderr, "  -V, --verbose     Log each API request and response to stderr, with keys masked\n")
+		fmt.Fprintf(os.Stderr, "      --piper-bin   Path to the piper executable (default: $PIPER_BIN or piper)\n")
+		fmt.Fprintf(os.Stderr, "      --resemble-project  Resemble project UUID to save clips to (default: $RESEMBLE_PROJECT)\n")
+		fmt.Fprintf(os.Stderr, "      --sample-rate  Output sample rate in Hz (ElevenLabs, Deepgram wav, pcm, and flac, and LMNT only)\n")
+		fmt.Fprintf(os.Stderr, "      --bitrate     Output bitrate in kbps (ElevenLabs mp3 and opus only), e.g. 192\n")
+		fmt.Fprintf(os.Stderr, "      --lang        Language to speak in, e.g. pt or es (ElevenLabs only)\n")
+		fmt.Fprintf(os.Stderr, "      --callback    Have Deepgram synthesize asynchronously and POST the audio to this URL\n")
+		fmt.Fprintf(os.Stderr, "      --proxy       Send API requests through this http, https, or socks5 proxy URL\n")
+		fmt.Fprintf(os.Stderr, "      --base-url    OpenAI-compatible API root or speech endpoint (default: $OPENAI_BASE_URL or OpenAI)\n")
+		fmt.Fprintf(os.Stderr, "      --diff        Speak only lines added since this file: --diff old.txt new.txt\n")
+		fmt.Fprintf(os.Stderr, "      --diff-announce  Prefix each added line with \"Added:\"\n")
+		fmt.Fprintf(os.Stderr, "      --locale      Read numbers using this locale's format (e.g. de-DE, en-US)\n")
+		fmt.Fprintf(os.Stderr, "      --output-template  Output filename template, e.g. 'clips/{{.Hash}}.mp3'\n")
+		fmt.Fprintf(os.Stderr, "      --no-clobber  Skip synthesis if the output file already exists\n")
+		fmt.Fprintf(os.Stderr, "      --providers-priority  Provider order for auto-selection and fallback, e.g. elevenlabs,openai\n")
+		fmt.Fprintf(os.Stderr, "      --caps-as     How to read ALL-CAPS words: normal, emphasis, spell (default: normal)\n")
+		fmt.Fprintf(os.Stderr, "      --detect-lang  Guess the text's language and pick a default voice that speaks it\n")
+		fmt.Fprintf(os.Stderr, "      --dict        Pronunciation dictionary: pattern=replacement per line, e.g. GIF=jiff\n")
+		fmt.Fprintf(os.Stderr, "      --all-providers  Speak with every provider that has an API key\n")
+		fmt.Fprintf(os.Stderr, "      --max-concurrent-providers  Providers synthesizing at once (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "      --markdown-structure  Narrate Markdown: announce headings, pause between list items\n")
+		fmt.Fprintf(os.Stderr, "      --markdown-code  Code blocks with --markdown-structure: skip, read (default: skip)\n")
+		fmt.Fprintf(os.Stderr, "      --strip-markdown  Remove Markdown syntax, keeping link text, before speaking\n")
+		fmt.Fprintf(os.Stderr, "      --strip-html  Remove HTML tags, keeping link text, before speaking\n")
+		fmt.Fprintf(os.Stderr, "      --ssml        Treat the input text as SSML (ElevenLabs, Google, Polly, Azure, Watson, Resemble, espeak)\n")
+		fmt.Fprintf(os.Stderr, "      --config      Read defaults from this config file\n")
+		fmt.Fprintf(os.Stderr, "      --profile     Load a named group of settings from the config file\n")
+		fmt.Fprintf(os.Stderr, "      --no-cache    Don't read or write the audio cache\n")
+		fmt.Fprintf(os.Stderr, "      --clear-cache  Remove all cached audio and exit\n")
+		fmt.Fprintf(os.Stderr, "      --list-voices  List the provider's voices, including custom ElevenLabs voices, and exit\n")
+		fmt.Fprintf(os.Stderr, "      --list-providers  List the providers, whether each is ready to use, and exit\n")
+		fmt.Fprintf(os.Stderr, "      --json        Print --list-voices or --list-providers output as JSON\n")
+		fmt.Fprintf(os.Stderr, "      --retries     Retries on 429/5xx responses (default: 3)\n")
+		fmt.Fprintf(os.Stderr, "      --retry-max-wait  Longest wait between retries (default: 30s)\n")
+		fmt.Fprintf(os.Stderr, "      --rps         Most API requests per second to each provider, 0 for no limit (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "      --timeout     Time limit for each API request attempt (default: 60s)\n")
+		fmt.Fprintf(os.Stderr, "      --statsd      Push request metrics to this StatsD address (host:port)\n")
+		fmt.Fprintf(os.Stderr, "  -h, --help        Show this help message\n\n")
+
+		fmt.Fprintf(os.Stderr, "OpenAI:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: OPENAI_API_KEY\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  alloy, echo, fable, onyx, nova, shimmer\n")
+		fmt.Fprintf(os.Stderr, "  Models:  tts-1, tts-1-hd, gpt-4o-mini-tts (default: tts-1-hd)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 4.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "ElevenLabs:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: ELEVENLABS_API_KEY\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  rachel, domi, bella, antoni, elli, josh, arnold,\n")
+		fmt.Fprintf(os.Stderr, "           adam, sam, george, charlie, emily, lily, michael\n")
+		fmt.Fprintf(os.Stderr, "           (or use a voice_id directly)\n")
+		fmt.Fprintf(os.Stderr, "  Models:  eleven_multilingual_v2 (default), eleven_turbo_v2_5,\n")
+		fmt.Fprintf(os.Stderr, "           eleven_turbo_v2, eleven_monolingual_v1\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.7 to 1.2\n\n")
+
+		fmt.Fprintf(os.Stderr, "Deepgram:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: DEEPGRAM_API_KEY\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  asteria (default), luna, stella, athena, hera, orion,\n")
+		fmt.Fprintf(os.Stderr, "           arcas, perseus, angus, orpheus, helios, zeus\n")
+		fmt.Fprintf(os.Stderr, "           Aura 2: thalia, andromeda, helena, jason, apollo, ares\n")
+		fmt.Fprintf(os.Stderr, "           (or use a model name directly like aura-asteria-en)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 4.0, by resampling (pitch changes too)\n\n")
+
+		fmt.Fprintf(os.Stderr, "Google:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: GOOGLE_API_KEY\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  wavenet-a, wavenet-b, wavenet-c, wavenet-d (default), wavenet-f,\n")
+		fmt.Fprintf(os.Stderr, "           neural2-a, neural2-c, neural2-d, neural2-f, studio-o, studio-q,\n")
+		fmt.Fprintf(os.Stderr, "           uk-wavenet-a, uk-wavenet-b, uk-neural2-a\n")
+		fmt.Fprintf(os.Stderr, "           (or use a voice name directly like en-US-Wavenet-D)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 4.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "AWS Polly:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  joanna (default), matthew, amy, brian, emma, ivy, joey,\n")
+		fmt.Fprintf(os.Stderr, "           justin, kendra, kimberly, salli, olivia, aria\n")
+		fmt.Fprintf(os.Stderr, "           (or use any Polly VoiceId directly)\n")
+		fmt.Fprintf(os.Stderr, "  Engines: neural (default), standard\n")
+		fmt.Fprintf(os.Stderr, "  Note:    Speed adjustment not supported\n\n")
+
+		fmt.Fprintf(os.Stderr, "Azure:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: AZURE_SPEECH_KEY, AZURE_SPEECH_REGION\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  jenny (default), guy, aria, davis, jane, jason, sara, tony,\n")
+		fmt.Fprintf(os.Stderr, "           ava, andrew, emma, brian, uk-sonia, uk-ryan, au-natasha, au-william\n")
+		fmt.Fprintf(os.Stderr, "           (or use a voice name directly like en-US-JennyNeural)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.5 to 2.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "Play.ht:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: PLAYHT_API_KEY, PLAYHT_USER_ID\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  jennifer (default), oliver\n")
+		fmt.Fprintf(os.Stderr, "           (or use a voice manifest URL, see --list-voices)\n")
+		fmt.Fprintf(os.Stderr, "  Models:  PlayHT2.0-turbo (default), PlayHT2.0, Play3.0-mini\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.1 to 5.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "IBM Watson:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: WATSON_API_KEY, WATSON_URL\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  allison (default), emily, henry, kevin, lisa, michael, olivia,\n")
+		fmt.Fprintf(os.Stderr, "           uk-charlotte, uk-james, uk-kate, au-heidi, au-jack\n")
+		fmt.Fprintf(os.Stderr, "           (or use a voice name directly like en-US_AllisonV3Voice)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.5 to 2.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "LMNT:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: LMNT_API_KEY\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  lily (default), amy, ansel, ava, caleb, chloe, daniel, juniper,\n")
+		fmt.Fprintf(os.Stderr, "           morgan, nathan, sophie, tyler, zeke (or a cloned voice's ID)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 2.0\n\n")
+
+		fmt.Fprintf(os.Stderr, "Resemble AI:\n")
+		fmt.Fprintf(os.Stderr, "  Env var: RESEMBLE_API_KEY, RESEMBLE_VOICE, RESEMBLE_PROJECT (optional)\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  a voice UUID from your account (required; list them with gospeak voices -p resemble)\n")
+		fmt.Fprintf(os.Stderr, "  Note:    Speed adjustment not supported\n\n")
+
+		fmt.Fprintf(os.Stderr, "Piper (offline):\n")
+		fmt.Fprintf(os.Stderr, "  Binary:  --piper-bin, $PIPER_BIN, or piper on the PATH\n")
+		fmt.Fprintf(os.Stderr, "  Model:   --model path/to/voice.onnx (required)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 4.0\n")
+		fmt.Fprintf(os.Stderr, "  Note:    No API key needed\n\n")
+
+		fmt.Fprintf(os.Stderr, "macOS say (offline):\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  any installed macOS voice, e.g. Samantha (list them with say -v '?')\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.25 to 4.0 (175 words per minute at 1.0)\n")
+		fmt.Fprintf(os.Stderr, "  Note:    No API key needed; used by default on macOS when OPENAI_API_KEY is unset\n\n")
+
+		fmt.Fprintf(os.Stderr, "espeak-ng (offline):\n")
+		fmt.Fprintf(os.Stderr, "  Voices:  any espeak-ng voice, e.g. en (default), en-us, de (list them with espeak-ng --voices)\n")
+		fmt.Fprintf(os.Stderr, "  Speed:   0.5 to 2.5 (175 words per minute at 1.0)\n")
+		fmt.Fprintf(os.Stderr, "  Note:    No API key needed; used by default elsewhere when OPENAI_API_KEY is unset and espeak-ng is installed\n\n")
+
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  gospeak \"Hello, world!\"\n")
+		fmt.Fprintf(os.Stderr, "  gospeak -p elevenlabs -v rachel \"Hello from ElevenLabs\"\n")
+		fmt.Fprintf(os.Stderr, "  gospeak -p deepgram -v asteria \"Hello from Deepgram\"\n")
+		fmt.Fprintf(os.Stderr, "  echo \"Hello\" | gospeak -v nova\n")
+		fmt.Fprintf(os.Stderr, "  gospeak -o output.mp3 \"Save this to a file\"\n")
+	}
+
+	// Hidden subcommand: the completion script is built from the flags
+	// defined above
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		if err := runCompletionCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	// Config file, GOSPEAK_*, and profile defaults, which command-line
+	// flags override
+	cfg, err := loadConfig(configPathFromArgs(os.Args[1:]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	profileName = profileFromArgs(os.Args[1:])
+	if err := applyDefaults(cfg, profileName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	flag.CommandLine.Parse(previewArgs(os.Args[1:]))
+
+	if help {
+		flag.Usage()
+		os.Exit(0)
+	}
+
+	if clearCache {
+		if err := runCacheCommand([]string{"clear"}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	// --ab compares setups given as provider:voice. The first is checked
+	// below like a single request; the rest when their requests are built.
+	var abSetups []abSetup
+	if len(abSpecs) > 0 {
+		if flagSet("provider", "p") || flagSet("voice", "v") || flagSet("model", "m") || providersPriority != "" {
+			fmt.Fprintln(os.Stderr, "Error: --ab gives each setup's provider and voice, so it cannot be used with --provider, --voice, --model, or --providers-priority")
+			exit(1)
+		}
+		if abSetups, err = parseABSetups(abSpecs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		// Defaults from the config file belong to whichever provider
+		// they were set for, so the first setup starts afresh
+		provider, voice, model = abSetups[0].provider, abSetups[0].voice, ""
+	}
+
+	// Normalize provider
+	provider = strings.ToLower(provider)
+	if !isValidProvider(provider) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Use %s\n", provider, providerChoices())
+		exit(1)
+	}
+
+	if timeout <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --timeout must be positive, e.g. 120s")
+		exit(1)
+	}
+	var proxy *url.URL
+	if proxyFlag != "" {
+		if proxy, err = parseProxy(proxyFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	httpClient = gospeak.NewHTTPClient(proxy)
+	httpClient.Timeout = timeout
+	if verbose {
+		httpClient.Transport = verboseTransport{httpClient.Transport}
+	}
+	// Count downloaded bytes for the progress display
+	httpClient.Transport = countingTransport{httpClient.Transport}
+
+	// --token wins over --token-file, which wins over the environment
+	if token == "" && tokenFile != "" {
+		token, err = readTokenFile(tokenFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --token-file: %v\n", err)
+			exit(1)
+		}
+	}
+
+	if listProvidersFlag {
+		if err := listProviders(voicesJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+	if listVoicesFlag {
+		if err := listVoices(provider, token, voicesJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	// Parse the provider priority list, used for auto-selection and fallback
+	var priority []string
+	if providersPriority != "" {
+		var err error
+		priority, err = parseProviderList(providersPriority)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --providers-priority: %v\n", err)
+			exit(1)
+		}
+		// --token belongs to the selected provider only, so other
+		// providers are considered usable when their env var is set, or
+		// when they need no key at all (Piper still needs a model)
+		var usable []string
+		for _, p := range priority {
+			if (!needsAPIKey(p) || apiKeyFor(p, "") != "") && (p != "piper" || defaultModelFor(p) != "") {
+				usable = append(usable, p)
+			}
+		}
+		if len(usable) == 0 && token == "" {
+			fmt.Fprintln(os.Stderr, "Error: None of the providers in --providers-priority has an API key set")
+			exit(1)
+		}
+		// Without an explicit --provider, pick the first usable one
+		if !flagSet("provider", "p") {
+			if len(usable) > 0 && token == "" {
+				provider = usable[0]
+			} else {
+				provider = priority[0]
+			}
+		}
+	}
+
+	// With nothing configured, speak offline with say on a Mac, or with
+	// espeak-ng elsewhere if it is installed, rather than fail for want of
+	// an OpenAI key
+	configuredProvider, _ := providerSetting(cfg, profileName)
+	if provider == defaultProvider && !flagSet("provider", "p") && configuredProvider == "" && priority == nil &&
+		!allProviders && apiKeyFor(provider, token) == "" && resolveOpenAIBaseURL(baseURLFlag) == "" {
+		if runtime.GOOS == "darwin" {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "%s not set, speaking with macOS say\n", providerEnvVars[provider])
+			}
+			provider = "say"
+		} else if _, err := exec.LookPath(gospeak.DefaultEspeakBin); err == nil {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "%s not set, speaking with espeak-ng\n", providerEnvVars[provider])
+			}
+			provider = "espeak"
+		}
+	}
+
+	// Set defaults based on provider, from its own environment variables
+	// or the built-in defaults
+	if voice == "" {
+		voice = defaultVoiceFor(provider)
+	}
+	if model == "" {
+		model = defaultModelFor(provider)
+	}
+	// An explicit --format wins; otherwise the --output extension says
+	// which format to request
+	if ext := extensionFormat(output); format != "" {
+		if ext != "" && ext != format {
+			fmt.Fprintf(os.Stderr, "Warning: Output file extension .%s does not match --format %s, using %s\n", ext, format, format)
+		}
+	} else if ext != "" {
+		format = ext
+	} else {
+		format = gospeak.DefaultFormat(provider)
+		if output != "" && output != "-" {
+			fmt.Fprintf(os.Stderr, "Warning: Unrecognized output extension '%s', saving as %s\n", filepath.Ext(output), format)
+		}
+	}
+
+	// A self-hosted OpenAI-compatible server may not need a key, and may
+	// offer voices beyond OpenAI's
+	openAIBaseURL = resolveOpenAIBaseURL(baseURLFlag)
+	customOpenAI := provider == "openai" && openAIBaseURL != ""
+
+	// Get API key
+	// (--all-providers looks up each provider's key separately)
+	apiKey := apiKeyFor(provider, token)
+	if apiKey == "" && !allProviders && needsAPIKey(provider) && !customOpenAI {
+		fmt.Fprintf(os.Stderr, "Error: %s environment variable not set and --token not provided\n", providerEnvVars[provider])
+		exit(1)
+	}
+	if provider == "piper" && model == "" {
+		fmt.Fprintln(os.Stderr, "Error: --model or PIPER_MODEL is required for Piper (path to a .onnx voice model)")
+		exit(1)
+	}
+	piperBin = resolvePiperBin(piperBinFlag)
+	resembleProject = resolveResembleProject(resembleFlag)
+	if provider == "resemble" && !allProviders && voice == "" {
+		fmt.Fprintln(os.Stderr, "Error: --voice or RESEMBLE_VOICE is required for Resemble (a voice UUID; list them with gospeak voices -p resemble)")
+		exit(1)
+	}
+	if provider == "say" && runtime.GOOS != "darwin" {
+		fmt.Fprintln(os.Stderr, "Error: The say provider needs macOS; use --provider espeak or piper for offline speech elsewhere")
+		exit(1)
+	}
+	if provider == "polly" && !allProviders {
+		if secretKey, _ := pollyCredentials(); secretKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: AWS_SECRET_ACCESS_KEY environment variable not set")
+			exit(1)
+		}
+		if !gospeak.IsValidPollyEngine(model) {
+			fmt.Fprintf(os.Stderr, "Error: Invalid Polly engine '%s'. Use %s\n", model, strings.Join(gospeak.PollyEngines, " or "))
+			exit(1)
+		}
+	}
+
+	if provider == "azure" && !allProviders && azureRegion() == "" {
+		fmt.Fprintln(os.Stderr, "Error: AZURE_SPEECH_REGION environment variable not set")
+		exit(1)
+	}
+
+	if provider == "watson" && !allProviders && watsonURL() == "" {
+		fmt.Fprintln(os.Stderr, "Error: WATSON_URL environment variable not set")
+		exit(1)
+	}
+	if provider == "playht" && !allProviders && playHTUserID() == "" {
+		fmt.Fprintln(os.Stderr, "Error: PLAYHT_USER_ID environment variable not set")
+		exit(1)
+	}
+
+	if provider == "elevenlabs" && !allProviders && !gospeak.IsValidElevenLabsModel(model) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid ElevenLabs model '%s'. Use %s\n", model, strings.Join(gospeak.ElevenLabsModels, ", "))
+		exit(1)
+	}
+
+	// --voice takes a comma-separated list too, spoken in turn as with
+	// --voices
+	if strings.Contains(voice, ",") && !allProviders {
+		if allFlag || voicesFlag != "" {
+			fmt.Fprintln(os.Stderr, "Error: --voice with several voices cannot be used with --all or --voices")
+			exit(1)
+		}
+		voicesFlag = voice
+	} else if !allProviders {
+		if _, err := resolveVoices(provider, voice); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// --all and --voices speak the same text with several voices in turn
+	var voiceList []string
+	if allFlag && voicesFlag != "" {
+		fmt.Fprintln(os.Stderr, "Error: --all and --voices cannot be used together")
+		exit(1)
+	}
+	if allFlag {
+		if voiceList = presetVoiceNames(provider); len(voiceList) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --all needs preset voices, and %s has none; list voices with --voices\n", provider)
+			exit(1)
+		}
+	} else if voicesFlag != "" {
+		if voiceList, err = resolveVoices(provider, voicesFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if len(voiceList) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --voices is empty")
+			exit(1)
+		}
+		voice = voiceList[0]
+	}
+	multiVoice := len(voiceList) > 0
+
+	// Validate the audio format (--all-providers uses each provider's default)
+	if err := gospeak.ValidateFormat(provider, format); err != nil && !allProviders {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	// --no-play wins over --speak, so nothing is heard whatever else is
+	// given
+	if noPlay {
+		if speak {
+			fmt.Fprintln(os.Stderr, "Warning: --no-play overrides --speak")
+			speak = false
+		}
+		if repl || follow {
+			fmt.Fprintln(os.Stderr, "Error: --no-play cannot be used with --repl or --follow")
+			exit(1)
+		}
+	}
+
+	// An external player may handle formats the built-in one can't
+	if !gospeak.IsPlayableFormat(format) && !allProviders && playCommand == "" && !noPlay {
+		if (output == "" && outputTemplate == "" && !batch && !multiVoice) || speak || (multiVoice && outputDir == "") {
+			fmt.Fprintf(os.Stderr, "Error: Cannot play %s audio; save it with --output instead, or use --play-command\n", format)
+			exit(1)
+		}
+		if timestampsPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: --timestamps-estimate needs mp3, wav, or opus audio")
+			exit(1)
+		}
+		if showDuration {
+			fmt.Fprintln(os.Stderr, "Error: --duration needs mp3, wav, or opus audio")
+			exit(1)
+		}
+		if srtPath != "" && provider != "elevenlabs" {
+			fmt.Fprintln(os.Stderr, "Error: --srt needs mp3, wav, or opus audio, except with ElevenLabs")
+			exit(1)
+		}
+	}
+
+	// Only ElevenLabs returns alignment; the estimate works for the rest
+	if alignPath != "" {
+		if timestampsPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: --timestamps and --timestamps-estimate cannot be combined")
+			exit(1)
+		}
+		if provider != "elevenlabs" || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --timestamps needs the elevenlabs provider; use --timestamps-estimate with others")
+			exit(1)
+		}
+	}
+	// Subtitles come from alignment when ElevenLabs can provide it, and
+	// are estimated otherwise
+	align := alignPath != "" || (srtPath != "" && provider == "elevenlabs" && !allProviders)
+
+	// The WebSocket API plays one text as it is synthesized
+	if streamInput {
+		if provider != "elevenlabs" || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --stream needs the elevenlabs provider")
+			exit(1)
+		}
+		if repl || follow || batch || multiVoice {
+			fmt.Fprintln(os.Stderr, "Error: --stream cannot be used with --repl, --follow, --batch, --all, or --voices")
+			exit(1)
+		}
+	}
+
+	// Deepgram can deliver the audio to a callback URL instead of
+	// answering with it, so there is nothing to play or save
+	if callbackURL != "" {
+		if provider != "deepgram" || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --callback needs the deepgram provider")
+			exit(1)
+		}
+		if u, err := url.Parse(callbackURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fmt.Fprintf(os.Stderr, "Error: Invalid --callback URL '%s'. Use an http:// or https:// URL\n", callbackURL)
+			exit(1)
+		}
+		if repl || follow || batch || multiVoice {
+			fmt.Fprintln(os.Stderr, "Error: --callback cannot be used with --repl, --follow, --batch, --all, or --voices")
+			exit(1)
+		}
+		if output != "" || speak || timestampsPath != "" || srtPath != "" || showDuration || normalize || trimSilence || pitch != 0 || speed != defaultSpeed {
+			fmt.Fprintln(os.Stderr, "Error: --callback sends the audio to the callback URL, so it cannot be used with --output, --speak, --timestamps-estimate, --srt, --duration, --normalize, --trim-silence, --pitch, or --speed")
+			exit(1)
+		}
+	}
+
+	// ElevenLabs names each sample rate and bitrate it offers for a
+	// format; the pair has to be one of them
+	if provider == "elevenlabs" && !allProviders {
+		if sampleRate < 0 || bitrate < 0 {
+			fmt.Fprintln(os.Stderr, "Error: --sample-rate and --bitrate cannot be negative")
+			exit(1)
+		}
+		if _, err := gospeak.ElevenLabsOutputFormat(format, sampleRate, bitrate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	} else if bitrate != 0 {
+		fmt.Fprintln(os.Stderr, "Error: --bitrate is only supported for ElevenLabs")
+		exit(1)
+	}
+
+	// Deepgram lets linear16 and FLAC output pick a sample rate; LMNT
+	// lets every format
+	if sampleRate != 0 && (provider != "elevenlabs" || allProviders) {
+		if (provider != "deepgram" && provider != "lmnt") || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --sample-rate is only supported for ElevenLabs, Deepgram, and LMNT")
+			exit(1)
+		}
+		name, rates := "LMNT", gospeak.LMNTSampleRates
+		if provider == "deepgram" {
+			name, rates = "Deepgram", gospeak.DeepgramSampleRates(format)
+		}
+		if rates == nil {
+			fmt.Fprintf(os.Stderr, "Error: Deepgram %s audio has a fixed sample rate; use --format wav, pcm, or flac with --sample-rate\n", format)
+			exit(1)
+		}
+		if !slices.Contains(rates, sampleRate) {
+			names := make([]string, len(rates))
+			for i, r := range rates {
+				names[i] = strconv.Itoa(r)
+			}
+			fmt.Fprintf(os.Stderr, "Error: Invalid sample rate %d for %s %s. Use %s\n", sampleRate, name, format, strings.Join(names, ", "))
+			exit(1)
+		}
+	}
+
+	// Only some providers interpret SSML; the rest would read the tags aloud
+	if ssml && !allProviders && !supportsSSML(provider) {
+		fmt.Fprintf(os.Stderr, "Error: SSML is not supported by %s. Use elevenlabs, google, polly, azure, watson, resemble, or espeak\n", provider)
+		exit(1)
+	}
+	if ssml && mdStructure {
+		fmt.Fprintln(os.Stderr, "Error: --ssml cannot be used with --markdown-structure")
+		exit(1)
+	}
+	if ssml && (stripMD || stripHTMLFlag) {
+		fmt.Fprintln(os.Stderr, "Error: --ssml cannot be used with --strip-markdown or --strip-html")
+		exit(1)
+	}
+	if stripMD && mdStructure {
+		fmt.Fprintln(os.Stderr, "Error: --strip-markdown cannot be used with --markdown-structure, which already handles Markdown")
+		exit(1)
+	}
+
+	// Voice instructions are an OpenAI feature of the gpt-4o TTS models
+	if instructions != "" {
+		if provider != "openai" || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --instructions is only supported for OpenAI")
+			exit(1)
+		}
+		if !gospeak.OpenAISupportsInstructions(model) {
+			fmt.Fprintf(os.Stderr, "Error: Model '%s' does not support --instructions. Use -m gpt-4o-mini-tts\n", model)
+			exit(1)
+		}
+	}
+
+	// A seed makes the providers that take one repeat themselves exactly
+	if seedFlag > math.MaxUint32 || (flagSet("seed") && seedFlag == 0) {
+		fmt.Fprintln(os.Stderr, "Error: --seed must be between 1 and 4294967295")
+		exit(1)
+	}
+	seed := uint32(seedFlag)
+	if seed != 0 && !allProviders && !supportsSeed(provider) {
+		fmt.Fprintf(os.Stderr, "Warning: %s doesn't support --seed, ignoring\n", provider)
+	}
+
+	// ElevenLabs style and speaker boost
+	if style < 0 || style > 1 {
+		fmt.Fprintln(os.Stderr, "Error: --style must be between 0.0 and 1.0")
+		exit(1)
+	}
+	if style != 0 && provider == "elevenlabs" && !gospeak.ElevenLabsSupportsStyle(model) {
+		fmt.Fprintf(os.Stderr, "Warning: Model '%s' ignores --style\n", model)
+	}
+	// ElevenLabs otherwise guesses the language from the text
+	if language != "" {
+		language = strings.ToLower(language)
+		if provider != "elevenlabs" || allProviders {
+			fmt.Fprintln(os.Stderr, "Error: --lang is only supported for ElevenLabs")
+			exit(1)
+		}
+		if !gospeak.IsValidElevenLabsLanguage(language) {
+			fmt.Fprintf(os.Stderr, "Error: Invalid language '%s'. Use %s\n", language, strings.Join(gospeak.ElevenLabsLanguages, ", "))
+			exit(1)
+		}
+		if !gospeak.ElevenLabsSupportsLanguage(model) {
+			fmt.Fprintf(os.Stderr, "Warning: Model '%s' ignores --lang; use -m eleven_flash_v2_5, eleven_turbo_v2_5, or eleven_v3 to set the language\n", model)
+		}
+	}
+	// Only send speaker boost when asked, so voices keep their own default
+	var speakerBoost *bool
+	if flagSet("speaker-boost") {
+		speakerBoost = &speakerBoostFlag
+	}
+
+	// Validate speed based on provider
+	if err := validateSpeed(provider, speed); err != nil && !allProviders {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	if resamplesSpeed(provider) && speed != defaultSpeed && !allProviders && output != "" && format != "wav" {
+		fmt.Fprintf(os.Stderr, "Warning: Speed can't be applied to saved %s audio from Deepgram; use --format wav\n", format)
+	}
+	if provider == "polly" && speed != defaultSpeed && !allProviders {
+		fmt.Fprintln(os.Stderr, "Warning: Speed adjustment is not supported for Polly, ignoring")
+	}
+	if provider == "resemble" && speed != defaultSpeed && !allProviders {
+		fmt.Fprintln(os.Stderr, "Warning: Speed adjustment is not supported for Resemble, ignoring")
+	}
+	if wpm < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --wpm must be a positive number of words per minute")
+		exit(1)
+	}
+	if len(abSetups) > 0 {
+		if repl || follow || batch || multiVoice || allProviders || callbackURL != "" || dryRunFlag || wpm != 0 || noPlay ||
+			output != "" || outputDir != "" || outputTemplate != "" || timestampsPath != "" || alignPath != "" || srtPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: --ab plays each setup in turn, so it cannot be used with --repl, --follow, --batch, --all, --voices, --all-providers, --callback, --dry-run, --wpm, --no-play, --output, --output-dir, --output-template, --timestamps, or --srt")
+			exit(1)
+		}
+	}
+	if preview > 0 && (repl || follow || batch || ssml) {
+		fmt.Fprintln(os.Stderr, "Error: --preview cuts one text short, so it cannot be used with --repl, --follow, --batch, or --ssml")
+		exit(1)
+	}
+	if wpm > 0 {
+		if repl || follow || batch || multiVoice || allProviders || callbackURL != "" || dryRunFlag {
+			fmt.Fprintln(os.Stderr, "Error: --wpm times the whole text first, so it cannot be used with --repl, --follow, --batch, --all, --voices, --all-providers, --callback, or --dry-run")
+			exit(1)
+		}
+		if _, ok := speedLimits[provider]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: --wpm needs a provider with speed control, which %s is not\n", provider)
+			exit(1)
+		}
+		if !gospeak.IsPlayableFormat(format) {
+			fmt.Fprintf(os.Stderr, "Error: --wpm times the audio, which can't be done with %s; use --format mp3, wav, or opus\n", format)
+			exit(1)
+		}
+		if flagSet("speed", "x") {
+			fmt.Fprintln(os.Stderr, "Warning: --wpm overrides --speed")
+		}
+	}
+
+	if maxRetries < 0 || retryMaxWait < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --retries and --retry-max-wait cannot be negative")
+		exit(1)
+	}
+	if maxRetries > retriesLimit {
+		fmt.Fprintf(os.Stderr, "Error: --retries can be at most %d\n", retriesLimit)
+		exit(1)
+	}
+	if providerRPS < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --rps cannot be negative")
+		exit(1)
+	}
+	if playbackRate == "auto" {
+		gospeak.SetPlaybackRate(gospeak.AutoPlaybackRate)
+	} else if playbackRate != "" {
+		rate, err := strconv.Atoi(playbackRate)
+		if err != nil || rate <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --playback-rate must be a positive sample rate or auto")
+			exit(1)
+		}
+		gospeak.SetPlaybackRate(rate)
+	}
+	if volume < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --volume must be between 0.0 and 1.0")
+		exit(1)
+	}
+	if volume > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: --volume %.2f amplifies the audio and may clip\n", volume)
+	}
+	gospeak.SetVolume(volume)
+	if normalizeLevel >= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --normalize-level must be below 0 dBFS, e.g. -20")
+		exit(1)
+	}
+	if !normalize {
+		normalizeLevel = 0
+	}
+	gospeak.SetNormalizeLevel(normalizeLevel)
+	if silenceThreshold >= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --silence-threshold must be below 0 dBFS, e.g. -50")
+		exit(1)
+	}
+	if !trimSilence {
+		silenceThreshold = 0
+	}
+	gospeak.SetTrimSilence(silenceThreshold)
+	if math.Abs(pitch) > gospeak.MaxPitch {
+		fmt.Fprintf(os.Stderr, "Error: --pitch must be between -%g and %g semitones\n", gospeak.MaxPitch, gospeak.MaxPitch)
+		exit(1)
+	}
+	if pitch != 0 && output != "" && format != "wav" {
+		fmt.Fprintf(os.Stderr, "Warning: --pitch can't be applied to saved %s audio; use --format wav\n", format)
+	}
+	gospeak.SetPitch(pitch)
+	if playCommand != "" {
+		gospeak.SetPlayCommand(strings.Fields(playCommand))
+	}
+	if repeat < 1 || repeatGap < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --repeat must be at least 1 and --repeat-gap cannot be negative")
+		exit(1)
+	}
+
+	if statsdAddr != "" {
+		m := newMetrics()
+		if err := m.enableStatsD(statsdAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		appMetrics = m
+	}
+
+	if maxConcurrent < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --max-concurrent-providers must be at least 1")
+		exit(1)
+	}
+	if concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --concurrency must be at least 1")
+		exit(1)
+	}
+
+	var budget float64
+	if budgetFlag != "" {
+		var err error
+		budget, err = parseBudget(budgetFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	if maxChars < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-chars cannot be negative")
+		exit(1)
+	}
+
+	if dryRunFlag && (multiVoice || allProviders || batch || repl || follow) {
+		fmt.Fprintln(os.Stderr, "Error: --dry-run cannot be used with --all, --voices, --all-providers, --batch, --repl, or --follow")
+		exit(1)
+	}
+
+	// Replacements for terms the providers mispronounce
+	var dict []dictEntry
+	if dictPath != "" {
+		dict, err = loadDict(dictPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --dict: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Keep reading lines and speaking them until EOF or :quit, or with
+	// --follow, as each line is piped in
+	if repl || follow {
+		if repl && follow {
+			fmt.Fprintln(os.Stderr, "Error: --follow cannot be used with --repl")
+			exit(1)
+		}
+		source := "--repl reads text from the prompt"
+		if follow {
+			source = "--follow reads text from piped input"
+		}
+		if inputFile != "" || diffPath != "" || flag.NArg() > 0 || batch || multiVoice || allProviders || output != "" || outputTemplate != "" || timestampsPath != "" || alignPath != "" || srtPath != "" || mdStructure {
+			fmt.Fprintf(os.Stderr, "Error: %s and cannot be used with text arguments, --file, --diff, --batch, --all, --voices, --all-providers, --output, --output-template, --timestamps, --timestamps-estimate, --srt, or --markdown-structure\n", source)
+			exit(1)
+		}
+		if follow && isTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Error: --follow needs piped input, e.g. tail -f app.log | gospeak --follow; use --repl to type lines")
+			exit(1)
+		}
+		prepare := func(line string) (string, error) {
+			if stripHTMLFlag {
+				line = stripHTML(line)
+			}
+			if stripMD {
+				line = stripMarkdown(line)
+			}
+			line = applyDict(line, dict)
+			line, err := applyCapsMode(line, strings.ToLower(capsAs))
+			if err != nil {
+				return "", err
+			}
+			if locale != "" {
+				nf, err := lookupNumberFormat(locale)
+				if err != nil {
+					return "", err
+				}
+				line = normalizeNumbers(line, nf)
+			}
+			return line, nil
+		}
+		base := speechRequest{
+			provider:        provider,
+			apiKey:          apiKey,
+			model:           model,
+			voice:           voice,
+			format:          format,
+			ssml:            ssml,
+			speed:           speed,
+			stability:       stability,
+			similarityBoost: similarityBoost,
+			style:           style,
+			speakerBoost:    speakerBoost,
+			instructions:    instructions,
+			sampleRate:      sampleRate,
+			bitrate:         bitrate,
+			language:        language,
+			seed:            seed,
+		}
+		if follow {
+			runFollow(base, prepare, budget, !noCache)
+		} else {
+			runREPL(base, prepare, budget, !noCache)
+		}
+		return
+	}
+
+	// Get text input, from exactly one source
+	var text string
+	stat, _ := os.Stdin.Stat()
+	stdinPiped := stat != nil && stat.Mode()&os.ModeCharDevice == 0
+	if inputFile != "" {
+		// Stdin isn't checked: under cron, CI, or systemd it is rarely a
+		// terminal even when nothing is piped, and --file wins anyway
+		if diffPath != "" || flag.NArg() > 0 {
+			fmt.Fprintln(os.Stderr, "Error: --file cannot be combined with text arguments or --diff")
+			exit(1)
+		}
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			exit(1)
+		}
+		text = strings.TrimSpace(string(data))
+	} else if diffPath != "" {
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Error: --diff requires the new file as an argument: gospeak --diff old.txt new.txt")
+			exit(1)
+		}
+		diff, err := diffText(diffPath, flag.Arg(0), diffAnnounce)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if diff == "" {
+			fmt.Fprintln(os.Stderr, "No additions to speak")
+			return
+		}
+		text = diff
+	} else if flag.NArg() > 0 {
+		text = strings.Join(flag.Args(), " ")
+	} else {
+		// Read from stdin
+		if stdinPiped {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+				exit(1)
+			}
+			text = strings.TrimSpace(string(data))
+		}
+	}
+
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "Error: No text provided")
+		flag.Usage()
+		exit(1)
+	}
+
+	// Drop markup that would otherwise be read out literally. HTML goes
+	// first, since Markdown may contain inline HTML.
+	if stripHTMLFlag {
+		text = stripHTML(text)
+	}
+	if stripMD {
+		text = stripMarkdown(text)
+	}
+	if (stripHTMLFlag || stripMD) && text == "" {
+		fmt.Fprintln(os.Stderr, "Error: No text left to speak after stripping markup")
+		exit(1)
+	}
+
+	// Narrate Markdown structure instead of reading the syntax
+	if mdStructure {
+		narrated, err := narrateMarkdown(text, strings.ToLower(mdCode))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if narrated == "" {
+			fmt.Fprintln(os.Stderr, "Error: No text to narrate after parsing Markdown")
+			exit(1)
+		}
+		text = narrated
+	}
+
+	// Respell terms from --dict, before ALL-CAPS handling so an acronym
+	// can be given its own pronunciation
+	text = applyDict(text, dict)
+
+	// Handle ALL-CAPS words consistently across providers
+	text, err = applyCapsMode(text, strings.ToLower(capsAs))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	// Rewrite locale-formatted numbers into their spoken form
+	if locale != "" {
+		nf, err := lookupNumberFormat(locale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		text = normalizeNumbers(text, nf)
+	}
+
+	// Guess the language, and swap the provider's built-in voice for one
+	// that speaks it. A voice chosen in the environment is kept.
+	if detectLang {
+		if lang := detectLanguage(text); lang != "" {
+			name := languageNames[lang]
+			if v, ok := languageVoices[provider][lang]; ok && voice == builtinVoiceFor(provider) && !multiVoice {
+				voice = v
+				if !quiet {
+					fmt.Fprintf(os.Stderr, "Detected %s, speaking with voice %s\n", name, voice)
+				}
+			} else if lang != "en" && speaksEnglishOnly(provider, voice, model) {
+				fmt.Fprintf(os.Stderr, "Warning: Text looks like %s, but the %s voice '%s' speaks only English\n", name, provider, voice)
+			} else if !quiet {
+				fmt.Fprintf(os.Stderr, "Detected %s\n", name)
+			}
+		}
+	}
+
+	// Speak just the start of a long text. The sample isn't cached, so it
+	// can't be mistaken for the whole text's audio later.
+	if preview > 0 {
+		var words int
+		if text, words = firstWords(text, int(preview)); words > int(preview) {
+			noCache = true
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "Speaking the first %d of %d words (preview only)\n", preview, words)
+			}
+		}
+	}
+
+	// In --batch mode every line of the file becomes its own clip
+	var lines []string
+	if batch {
+		if inputFile == "" || outputDir == "" {
+			fmt.Fprintln(os.Stderr, "Error: --batch needs --file and --output-dir")
+			exit(1)
+		}
+		if multiVoice || allProviders || output != "" || outputTemplate != "" || timestampsPath != "" || alignPath != "" || srtPath != "" || mdStructure || stripMD {
+			fmt.Fprintln(os.Stderr, "Error: --batch cannot be used with --all, --voices, --all-providers, --output, --output-template, --timestamps, --timestamps-estimate, --srt, --markdown-structure, or --strip-markdown")
+			exit(1)
+		}
+		lines = batchLines(text)
+		if len(lines) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No lines to synthesize in --file")
+			exit(1)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	} else if multiVoice && outputDir != "" {
+		if output != "" {
+			fmt.Fprintln(os.Stderr, "Error: --output-dir with --all or --voices cannot be used with --output; use --output-template to name the files")
+			exit(1)
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	} else if outputDir != "" {
+		fmt.Fprintln(os.Stderr, "Error: --output-dir requires --batch, --all, or --voices")
+		exit(1)
+	}
+
+	// Expand a templated output filename; with --all or --voices it names
+	// each voice's file instead, below
+	if outputTemplate != "" && !multiVoice {
+		if output != "" {
+			fmt.Fprintln(os.Stderr, "Error: --output and --output-template cannot be used together")
+			exit(1)
+		}
+		hash := speechRequest{
+			provider:        provider,
+			model:           model,
+			voice:           voice,
+			text:            text,
+			format:          format,
+			ssml:            ssml,
+			speed:           speed,
+			stability:       stability,
+			similarityBoost: similarityBoost,
+			style:           style,
+			speakerBoost:    speakerBoost,
+			instructions:    instructions,
+			sampleRate:      sampleRate,
+			bitrate:         bitrate,
+			language:        language,
+			seed:            seed,
+		}.cacheKey()
+		name, err := renderOutputName(outputTemplate, outputNameData{
+			Hash:      hash,
+			ShortHash: hash[:12],
+			Provider:  provider,
+			Voice:     voice,
+			Model:     model,
+			Ext:       format,
+			Date:      time.Now().Format(time.DateOnly),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		output = name
+	}
+	// Only saved MP3s carry tags
+	if (tagTitle != "" || tagArtist != "") && (output == "" || format != "mp3") {
+		fmt.Fprintln(os.Stderr, "Warning: --tag-title and --tag-artist only apply to MP3 audio saved with --output, ignoring")
+	}
+
+	// --output - writes the audio to stdout, for piping into another
+	// program; every message already goes to stderr
+	toStdout := output == "-"
+	if toStdout && isTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stderr, "Error: Refusing to write audio to a terminal; pipe or redirect stdout")
+		exit(1)
+	}
+
+	// Ctrl+C or SIGTERM cancels in-flight synthesis and stops playback
+	ctx := interruptContext()
+
+	// Reuse an existing output file instead of synthesizing again
+	if noClobber && output != "" && !toStdout && !multiVoice {
+		if existing, err := os.ReadFile(output); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipping synthesis, %s already exists\n", output)
+			if speak {
+				if err := gospeak.Play(ctx, existing); err != nil {
+					exitIfInterrupted(ctx, err)
+					fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+					exit(1)
+				}
+			}
+			return
+		}
+	}
+
+	// Synthesize speech
+	req := speechRequest{
+		provider:        provider,
+		apiKey:          apiKey,
+		model:           model,
+		voice:           voice,
+		text:            text,
+		format:          format,
+		ssml:            ssml,
+		speed:           speed,
+		stability:       stability,
+		similarityBoost: similarityBoost,
+		style:           style,
+		speakerBoost:    speakerBoost,
+		instructions:    instructions,
+		sampleRate:      sampleRate,
+		bitrate:         bitrate,
+		language:        language,
+		seed:            seed,
+	}
+
+	// In --ab mode each setup speaks the text after its letter
+	var abReqs []speechRequest
+	if len(abSetups) > 0 {
+		if abReqs, err = abRequests(req, abSetups); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Enforce the monthly budget before spending anything
+	chars := utf8.RuneCountInString(text)
+	requestChars := chars
+	if multiVoice {
+		requestChars = 0
+		for _, v := range voiceList {
+			requestChars += chars
+			// Announcements are only made when playing
+			if (outputDir == "" || speak) && !noPlay {
+				requestChars += len(v)
+			}
+		}
+	}
+	if batch {
+		requestChars = 0
+		for _, line := range lines {
+			requestChars += utf8.RuneCountInString(line)
+		}
+	}
+	// --wpm synthesizes the text once more to time it, unless the speed
+	// is changed by resampling that first clip
+	if wpm > 0 && !resamplesSpeed(provider) {
+		requestChars *= 2
+	}
+	// A hard stop for pasting a whole document by accident. It counts all
+	// of the text, however many chunks or lines it is sent as.
+	inputChars := chars
+	if batch {
+		inputChars = requestChars
+	}
+	if maxChars > 0 && inputChars > maxChars {
+		fmt.Fprintf(os.Stderr, "Error: Text is %d characters, over the --max-chars limit of %d\n", inputChars, maxChars)
+		exit(1)
+	}
+	usage := openUsage(budget)
+	estimated := estimateCost(provider, model, requestChars)
+
+	// In --all-providers mode, every provider with a key gets the same text
+	var providerReqs []speechRequest
+	if allProviders {
+		if multiVoice || output != "" {
+			fmt.Fprintln(os.Stderr, "Error: --all-providers cannot be used with --all, --voices, or --output")
+			exit(1)
+		}
+		order := providerNames
+		if priority != nil {
+			order = priority
+		}
+		estimated = 0
+		for _, p := range order {
+			key := apiKeyFor(p, "")
+			// Resemble has no default voice to fall back on
+			if key == "" || (ssml && !supportsSSML(p)) || (p == "resemble" && defaultVoiceFor(p) == "") {
+				continue
+			}
+			r := speechRequest{
+				provider:        p,
+				apiKey:          key,
+				model:           defaultModelFor(p),
+				voice:           defaultVoiceFor(p),
+				text:            text,
+				format:          gospeak.DefaultFormat(p),
+				ssml:            ssml,
+				speed:           speed,
+				stability:       stability,
+				similarityBoost: similarityBoost,
+				style:           style,
+				speakerBoost:    speakerBoost,
+				seed:            seed,
+			}
+			if validateSpeed(p, r.speed) != nil || !supportsSpeed(p) {
+				r.speed = defaultSpeed
+			}
+			providerReqs = append(providerReqs, r)
+			estimated += estimateCost(p, r.model, chars)
+		}
+		if len(providerReqs) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No provider API keys found in the environment")
+			exit(1)
+		}
+		requestChars = chars * len(providerReqs)
+	}
+	if len(abReqs) > 0 {
+		estimated, requestChars = 0, 0
+		for i, r := range abReqs {
+			n := chars + len(abLabel(i))
+			estimated += estimateCost(r.provider, r.model, n)
+			requestChars += n
+		}
+	}
+	if budget > 0 && !dryRunFlag && usage.Spend+estimated > budget {
+		fmt.Fprintf(os.Stderr, "Error: Monthly budget of $%.2f would be exceeded (spent $%.2f, this request ~$%.4f)\n", budget, usage.Spend, estimated)
+		exit(1)
+	}
+
+	// Show what the request will cost and ask before spending it
+	if estimateFlag && !dryRunFlag {
+		if allProviders {
+			for _, r := range providerReqs {
+				fmt.Fprintf(os.Stderr, "%s (%s): %d characters, ~$%.4f\n", r.provider, r.model, chars, estimateCost(r.provider, r.model, chars))
+			}
+			fmt.Fprintf(os.Stderr, "Estimated total: $%.4f\n", estimated)
+		} else if len(abReqs) > 0 {
+			for i, r := range abReqs {
+				n := chars + len(abLabel(i))
+				fmt.Fprintf(os.Stderr, "%s: %s (%s): %d characters, ~$%.4f\n", abLabel(i), r.provider, r.model, n, estimateCost(r.provider, r.model, n))
+			}
+			fmt.Fprintf(os.Stderr, "Estimated total: $%.4f\n", estimated)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s (%s): %d characters, ~$%.4f\n", provider, model, requestChars, estimated)
+		}
+		if !yes {
+			if !isTerminal(os.Stdin) {
+				fmt.Fprintln(os.Stderr, "Error: --estimate can't ask for confirmation when stdin isn't a terminal; add --yes")
+				exit(1)
+			}
+			if !confirm("Continue?") {
+				fmt.Fprintln(os.Stderr, "Cancelled")
+				return
+			}
+		}
+	}
+	recordUsage := func(provider, model string, n int) {
+		usage.add(provider, model, n)
+		if err := usage.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save usage: %v\n", err)
+		}
+	}
+	printCost := func() {
+		if !showCost {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Estimated cost: $%.4f (%d characters)\n", estimated, requestChars)
+		if budget > 0 {
+			fmt.Fprintf(os.Stderr, "Spent this month: $%.4f of $%.2f ($%.4f remaining)\n", usage.Spend, budget, max(budget-usage.Spend, 0))
+		} else {
+			fmt.Fprintf(os.Stderr, "Spent this month: $%.4f\n", usage.Spend)
+		}
+	}
+
+	// Handle --all-providers: synthesize concurrently, then play in order
+	if allProviders {
+		status := newStatusLine()
+		status.set("Synthesizing 0/%d providers...", len(providerReqs))
+		results, errs := synthesizeConcurrently(ctx, providerReqs, maxConcurrent, func(done int) {
+			status.set("Synthesizing %d/%d providers...", done, len(providerReqs))
+		})
+		status.clear()
+		for i, r := range providerReqs {
+			if errs[i] != nil {
+				exitIfInterrupted(ctx, errs[i])
+				fmt.Fprintf(os.Stderr, "Error synthesizing with %s: %v\n", r.provider, errs[i])
+				continue
+			}
+			recordUsage(r.provider, r.model, chars)
+			if noPlay {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Speaking with provider: %s (%s)\n", r.provider, r.voice)
+			if err := gospeak.Play(ctx, results[i]); err != nil {
+				exitIfInterrupted(ctx, err)
+				fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+			}
+		}
+		printCost()
+		return
+	}
+
+	// Handle --ab: each setup in turn, the next synthesized while the
+	// last plays
+	if len(abReqs) > 0 {
+		speakAB(ctx, abReqs, !noCache, allGap, concurrency, func(r speechRequest) {
+			recordUsage(r.provider, r.model, utf8.RuneCountInString(r.text))
+		})
+		printCost()
+		return
+	}
+
+	// Handle --all and --voices: each voice in turn
+	if multiVoice {
+		if timestampsPath != "" || alignPath != "" || srtPath != "" {
+			fmt.Fprintln(os.Stderr, "Error: --timestamps, --timestamps-estimate, and --srt cannot be used with --all or --voices")
+			exit(1)
+		}
+		if outputTemplate != "" && outputDir == "" {
+			fmt.Fprintln(os.Stderr, "Error: --output-template with --all or --voices needs --output-dir")
+			exit(1)
+		}
+		var paths []string
+		if outputDir != "" {
+			if paths, err = voicePaths(req, voiceList, outputDir, outputTemplate); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+		}
+		if allGap < 0 {
+			fmt.Fprintln(os.Stderr, "Error: --all-gap cannot be negative")
+			exit(1)
+		}
+		// Saved clips are only played, and announced, with --speak
+		play := (outputDir == "" || speak) && !noPlay
+		speakWithVoices(ctx, req, voiceList, paths, play, announce, noClobber, !noCache, allGap, concurrency, func(r speechRequest) {
+			recordUsage(r.provider, r.model, utf8.RuneCountInString(r.text))
+		})
+		printCost()
+		return
+	}
+
+	// Handle --dry-run: show what would be sent and stop
+	if dryRunFlag {
+		if err := dryRun(ctx, req, callbackURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	// Handle --callback: submit the job and print its ID, leaving the
+	// audio to the callback
+	if callbackURL != "" {
+		ids, err := submitAsync(ctx, req, callbackURL)
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+		if len(ids) > 0 {
+			recordUsage(req.provider, req.model, utf8.RuneCountInString(req.text))
+		}
+		if err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error submitting to Deepgram: %v\n", err)
+			exit(1)
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Submitted %d Deepgram request(s); the audio will be sent to %s\n", len(ids), callbackURL)
+		}
+		printCost()
+		return
+	}
+
+	// Handle --batch: one numbered file per line
+	if batch {
+		failed := runBatch(ctx, req, lines, outputDir, concurrency, !noCache, noClobber, speak, func(r speechRequest) {
+			recordUsage(r.provider, r.model, utf8.RuneCountInString(r.text))
+		})
+		printCost()
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "Error: %d of %d lines failed\n", failed, len(lines))
+			exit(1)
+		}
+		return
+	}
+
+	// Handle --wpm: time the text at normal speed and pick the speed that
+	// reads it at the target rate
+	var timed []byte
+	if wpm > 0 {
+		if req.speed, timed, err = calibrateSpeed(ctx, req, wpm, !noCache, recordUsage); err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error timing speech for --wpm: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Reuse the audio from an identical earlier request. The cache has no
+	// alignment, so requests that need it always ask the provider.
+	var audioData []byte
+	var aligned []charTiming
+	cached := false
+	if !noCache && !align {
+		audioData, cached = cacheLoad(req.cacheKey(), req.format)
+		appMetrics.observeCache(req.provider, req.voice, cached)
+	}
+	// The clip already timed is resampled to the new speed like a cached
+	// one, and is in the cache already if caching is on
+	if timed != nil && resamplesSpeed(req.provider) && !cached && !align {
+		audioData, cached = timed, true
+	}
+	saveToCache := func(data []byte) {
+		if noCache {
+			return
+		}
+		if err := cacheStore(req.cacheKey(), req, data); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+		}
+	}
+
+	// Stream straight to the speaker when nothing needs the whole clip
+	resampled := resamplesSpeed(req.provider) && req.speed != defaultSpeed
+	streaming := !cached && output == "" && !noPlay && timestampsPath == "" && srtPath == "" && !align && !showDuration && !resampled && !normalize && !trimSilence && pitch == 0
+	if streamInput && !streaming && !cached {
+		fmt.Fprintln(os.Stderr, "Error: --stream plays audio as it arrives, so it cannot be used with --output, --no-play, --timestamps, --timestamps-estimate, --srt, --duration, --normalize, --trim-silence, or --pitch")
+		exit(1)
+	}
+	var stream io.ReadCloser
+	attempt := func(r speechRequest) error {
+		stop := showProgress("Synthesizing with " + r.provider)
+		defer stop()
+		var err error
+		switch {
+		// A fallback provider streams over HTTP instead
+		case streaming && streamInput && r.provider == "elevenlabs":
+			stream, err = synthesizeStreamInput(ctx, r)
+		case streaming:
+			stream, err = synthesizeStream(ctx, r)
+		case align:
+			audioData, aligned, err = synthesizeAligned(ctx, r)
+		default:
+			audioData, err = synthesize(ctx, r)
+		}
+		return err
+	}
+	if !cached {
+		err = attempt(req)
+	}
+
+	// Fall back to the next usable provider in priority order
+	for _, p := range priority {
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+		if p == req.provider || (align && p != "elevenlabs") || (needsAPIKey(p) && apiKeyFor(p, "") == "") || (p == "resemble" && defaultVoiceFor(p) == "") || (p == "piper" && defaultModelFor(p) == "") || gospeak.ValidateFormat(p, req.format) != nil || (req.ssml && !supportsSSML(p)) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s failed (%v), falling back to %s\n", req.provider, err, p)
+		req.provider = p
+		req.apiKey = apiKeyFor(p, "")
+		req.voice = defaultVoiceFor(p)
+		req.model = defaultModelFor(p)
+		if validateSpeed(p, req.speed) != nil {
+			req.speed = defaultSpeed
+		}
+		err = attempt(req)
+	}
+	provider, voice, model = req.provider, req.voice, req.model
+
+	if err != nil {
+		exitIfInterrupted(ctx, err)
+		fmt.Fprintf(os.Stderr, "Error synthesizing speech: %v\n", err)
+		exit(1)
+	}
+	if !cached {
+		recordUsage(provider, model, chars)
+	}
+	printCost()
+
+	if streaming {
+		// Keep a copy of what was played so it can be cached afterwards
+		var played bytes.Buffer
+		err := gospeak.PlayStream(ctx, io.TeeReader(stream, &played))
+		if err == nil {
+			_, err = io.Copy(&played, stream)
+			if err == nil {
+				saveToCache(played.Bytes())
+				err = replay(ctx, played.Bytes(), repeat-1, repeatGap)
+			}
+		}
+		stream.Close()
+		if err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+	if !cached {
+		saveToCache(audioData)
+	}
+
+	// Change the speed after synthesis when the provider can't. Only WAV
+	// can be written back; other formats still play at the new speed.
+	played := audioData
+	if resamplesSpeed(req.provider) && req.speed != defaultSpeed && gospeak.IsPlayableFormat(req.format) {
+		played, err = gospeak.ChangeSpeed(audioData, req.speed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error changing speed: %v\n", err)
+			exit(1)
+		}
+		if req.format == "wav" {
+			audioData = played
+		}
+	}
+	if audioData, err = processSaved(audioData, req.format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error processing audio: %v\n", err)
+		exit(1)
+	}
+
+	// Tag a saved MP3 so music players show more than "Unknown"
+	if output != "" && req.format == "mp3" {
+		if tagged, err := gospeak.TagMP3(audioData, mp3Tags(req, tagTitle, tagArtist)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to tag MP3: %v\n", err)
+		} else {
+			audioData = tagged
+		}
+	}
+
+	// Save to file if requested
+	if toStdout {
+		if _, err := os.Stdout.Write(audioData); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing audio to stdout: %v\n", err)
+			exit(1)
+		}
+	} else if output != "" {
+		if err := writeFileAtomic(output, audioData, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving file: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved to %s\n", output)
+	}
+
+	// Measure the clip for --duration, timestamps, and subtitles
+	var duration time.Duration
+	if showDuration || timestampsPath != "" || align || srtPath != "" {
+		duration, err = gospeak.AudioDuration(played)
+		// Alignment says when the speech ends, even for formats that
+		// can't be measured
+		if err != nil && len(aligned) > 0 {
+			duration, err = time.Duration(aligned[len(aligned)-1].End*float64(time.Second)), nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error measuring audio: %v\n", err)
+			exit(1)
+		}
+	}
+	if showDuration {
+		fmt.Fprintf(os.Stderr, "Duration: %s\n", duration.Round(10*time.Millisecond))
+	}
+
+	// Write estimated word timestamps if requested
+	if timestampsPath != "" {
+		if err := writeTimings(timestampsPath, estimateWordTimings(text, duration), nil, duration); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving timestamps: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved timestamps to %s\n", timestampsPath)
+	}
+	if alignPath != "" {
+		if err := writeTimings(alignPath, alignedWordTimings(aligned), aligned, duration); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving timestamps: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved timestamps to %s\n", alignPath)
+	}
+	if srtPath != "" {
+		words := alignedWordTimings(aligned)
+		if !align {
+			words = estimateWordTimings(text, duration)
+		}
+		if err := writeSRT(srtPath, sentenceCues(text, words)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving subtitles: %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Saved subtitles to %s\n", srtPath)
+	}
+
+	// Play audio if no output file or if --speak flag is set; --no-play
+	// overrides both
+	if (output == "" || speak) && !noPlay {
+		err := gospeak.Play(ctx, played)
+		if err == nil {
+			err = replay(ctx, played, repeat-1, repeatGap)
+		}
+		if err != nil {
+			exitIfInterrupted(ctx, err)
+			fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+			exit(1)
+		}
+	}
+}
+
+// replay plays audioData again the given number of times, pausing gap
+// before each, for --repeat.
+func replay(ctx context.Context, audioData []byte, times int, gap time.Duration) error {
+	for range times {
+		select {
+		case <-time.After(gap):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := gospeak.Play(ctx, audioData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeLevel is the --normalize-level target in dBFS when
+// --normalize is set, and zero otherwise. silenceThreshold is likewise
+// the --silence-threshold when --trim-silence is set, and pitch is the
+// --pitch shift in semitones.
+var normalizeLevel, silenceThreshold, pitch float64
+
+// processSaved trims, pitch-shifts, and normalizes a clip about to be
+// saved, for --trim-silence, --pitch, and --normalize. Only WAV is
+// rewritten; other formats are saved as the provider sent them, and
+// processed only when played.
+func processSaved(audioData []byte, format string) ([]byte, error) {
+	if format != "wav" {
+		return audioData, nil
+	}
+	var err error
+	if silenceThreshold != 0 {
+		if audioData, err = gospeak.TrimSilence(audioData, silenceThreshold); err != nil {
+			return nil, err
+		}
+	}
+	if pitch != 0 {
+		if audioData, err = gospeak.PitchShift(audioData, pitch); err != nil {
+			return nil, err
+		}
+	}
+	if normalizeLevel != 0 {
+		if audioData, err = gospeak.Normalize(audioData, normalizeLevel); err != nil {
+			return nil, err
+		}
+	}
+	return audioData, nil
+}
+
+// flagSet reports whether any of the named flags was given on the command line.
+func flagSet(names ...string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		for _, name := range names {
+			if f.Name == name {
+				set = true
+			}
+		}
+	})
+	return set
+}
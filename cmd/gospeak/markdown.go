@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdSetextUnder = regexp.MustCompile(`^(=+|-+)\s*$`)
+	mdListItem    = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.*)$`)
+	mdRule        = regexp.MustCompile(`^\s*(?:[-*_]\s*){3,}$`)
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)")
+	mdTableSep    = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	mdRefDef      = regexp.MustCompile(`^\s*\[[^\]]+\]:\s+\S+`)
+
+	mdImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdRefLink    = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	mdAutoLink   = regexp.MustCompile(`<(https?://[^>]+)>`)
+	mdInlineCode = regexp.MustCompile("`([^`]*)`")
+	mdStrong     = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	mdEmphasis   = regexp.MustCompile(`(^|[^\w*])[*_]([^*_]+)[*_]`)
+	mdStrike     = regexp.MustCompile(`~~(.+?)~~`)
+)
+
+// Valid values for --markdown-code
+var markdownCodeModes = []string{"skip", "read"}
+
+// stripInlineMarkdown removes inline Markdown syntax, keeping link text and
+// image alt text but dropping URLs.
+func stripInlineMarkdown(s string) string {
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdRefLink.ReplaceAllString(s, "$1")
+	s = mdAutoLink.ReplaceAllString(s, "$1")
+	s = mdInlineCode.ReplaceAllString(s, "$1")
+	s = mdStrong.ReplaceAllString(s, "$2")
+	s = mdEmphasis.ReplaceAllString(s, "$1$2")
+	s = mdStrike.ReplaceAllString(s, "$1")
+	return s
+}
+
+// narrateMarkdown turns a Markdown document into text suited for reading
+// aloud: headings are announced, list items and table rows become short
+// sentences so the voice pauses between them, and fenced code blocks are
+// skipped or read verbatim depending on codeMode.
+func narrateMarkdown(doc, codeMode string) (string, error) {
+	switch codeMode {
+	case "skip", "read":
+	default:
+		return "", fmt.Errorf("invalid --markdown-code '%s'. Use %s", codeMode, strings.Join(markdownCodeModes, ", "))
+	}
+
+	lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+	var out []string
+	var paragraph []string
+	inCode := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, sentence(strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if mdFence.MatchString(line) {
+			flush()
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			if codeMode == "read" && trimmed != "" {
+				out = append(out, trimmed)
+			}
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flush()
+		case mdHeading.MatchString(trimmed):
+			flush()
+			title := mdHeading.FindStringSubmatch(trimmed)[2]
+			out = append(out, "Heading: "+sentence(stripInlineMarkdown(title)))
+		case len(paragraph) > 0 && mdSetextUnder.MatchString(trimmed):
+			// The paragraph so far was a setext heading
+			title := strings.Join(paragraph, " ")
+			paragraph = nil
+			out = append(out, "Heading: "+sentence(stripInlineMarkdown(title)))
+		case mdRule.MatchString(trimmed):
+			flush()
+		case mdListItem.MatchString(line):
+			flush()
+			item := mdListItem.FindStringSubmatch(line)[1]
+			out = append(out, sentence(stripInlineMarkdown(item)))
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			quote := strings.TrimSpace(strings.TrimLeft(trimmed, "> "))
+			if quote != "" {
+				out = append(out, "Quote: "+sentence(stripInlineMarkdown(quote)))
+			}
+		case strings.HasPrefix(trimmed, "|"):
+			flush()
+			if mdTableSep.MatchString(trimmed) {
+				continue
+			}
+			var cells []string
+			for _, cell := range strings.Split(strings.Trim(trimmed, "|"), "|") {
+				if cell = strings.TrimSpace(stripInlineMarkdown(cell)); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+			if len(cells) > 0 {
+				out = append(out, sentence(strings.Join(cells, ", ")))
+			}
+		default:
+			paragraph = append(paragraph, stripInlineMarkdown(trimmed))
+		}
+	}
+	flush()
+
+	return strings.Join(out, "\n"), nil
+}
+
+// stripMarkdown removes Markdown syntax for --strip-markdown, leaving the
+// text to be read as written: heading markers, list bullets, quote
+// markers, rules, and code fences are dropped, while link text, image alt
+// text, and the contents of code blocks are kept. Unlike narrateMarkdown
+// nothing is announced or rearranged.
+func stripMarkdown(doc string) string {
+	lines := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case mdHeading.MatchString(trimmed):
+			line = mdHeading.FindStringSubmatch(trimmed)[2]
+		case mdSetextUnder.MatchString(trimmed) && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "",
+			mdRule.MatchString(trimmed), mdTableSep.MatchString(trimmed), mdRefDef.MatchString(trimmed):
+			continue
+		case mdListItem.MatchString(line):
+			line = mdListItem.FindStringSubmatch(line)[1]
+		case strings.HasPrefix(trimmed, ">"):
+			line = strings.TrimLeft(trimmed, "> ")
+		case strings.HasPrefix(trimmed, "|"):
+			var cells []string
+			for _, cell := range strings.Split(strings.Trim(trimmed, "|"), "|") {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+			line = strings.Join(cells, ", ")
+		}
+		out = append(out, stripInlineMarkdown(line))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// sentence ensures s ends with punctuation so the voice pauses after it.
+func sentence(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	if unicode.IsPunct(r[len(r)-1]) {
+		return s
+	}
+	return s + "."
+}
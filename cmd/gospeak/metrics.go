@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// appMetrics collects per-request metrics. It is nil unless metrics are
+// enabled, and every method is a no-op on a nil receiver, so disabled
+// metrics cost nothing beyond a nil check.
+var appMetrics *metrics
+
+// Latency histogram bucket upper bounds, in seconds
+var latencyBuckets = []float64{0.25, 0.5, 1, 2, 5, 10, 30, 60}
+
+type metricLabels struct {
+	provider string
+	voice    string
+}
+
+type metricSeries struct {
+	requests    int64
+	errors      int64
+	bytes       int64
+	cacheHits   int64
+	cacheMisses int64
+	latencySum  float64
+	buckets     []int64 // cumulative counts per latencyBuckets entry
+}
+
+type metrics struct {
+	mu     sync.Mutex
+	series map[metricLabels]*metricSeries
+	statsd net.Conn
+}
+
+func newMetrics() *metrics {
+	return &metrics{series: map[metricLabels]*metricSeries{}}
+}
+
+// enableStatsD makes m also push every observation to a StatsD server at
+// addr (host:port, UDP). Tags use the DogStatsD "|#key:value" extension.
+func (m *metrics) enableStatsD(addr string) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to StatsD at %s: %w", addr, err)
+	}
+	m.statsd = conn
+	return nil
+}
+
+func (m *metrics) get(l metricLabels) *metricSeries {
+	s, ok := m.series[l]
+	if !ok {
+		s = &metricSeries{buckets: make([]int64, len(latencyBuckets))}
+		m.series[l] = s
+	}
+	return s
+}
+
+// observeRequest records one synthesis request.
+func (m *metrics) observeRequest(provider, voice string, latency time.Duration, n int, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	s := m.get(metricLabels{provider, voice})
+	s.requests++
+	s.bytes += int64(n)
+	if err != nil {
+		s.errors++
+	}
+	secs := latency.Seconds()
+	s.latencySum += secs
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			s.buckets[i]++
+		}
+	}
+	m.mu.Unlock()
+
+	tags := statsdTags(provider, voice)
+	m.push("gospeak.requests:1|c" + tags)
+	m.push(fmt.Sprintf("gospeak.request.latency:%d|ms%s", latency.Milliseconds(), tags))
+	m.push(fmt.Sprintf("gospeak.audio.bytes:%d|c%s", n, tags))
+	if err != nil {
+		m.push("gospeak.errors:1|c" + tags)
+	}
+}
+
+// observeCache records a cache lookup.
+func (m *metrics) observeCache(provider, voice string, hit bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	s := m.get(metricLabels{provider, voice})
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+	m.mu.Unlock()
+
+	if hit {
+		m.push("gospeak.cache.hits:1|c" + statsdTags(provider, voice))
+	} else {
+		m.push("gospeak.cache.misses:1|c" + statsdTags(provider, voice))
+	}
+}
+
+func (m *metrics) push(line string) {
+	if m.statsd != nil {
+		// Best effort: StatsD is fire-and-forget over UDP
+		m.statsd.Write([]byte(line))
+	}
+}
+
+func statsdTags(provider, voice string) string {
+	return "|#provider:" + provider + ",voice:" + voice
+}
+
+// writePrometheus writes all metrics in the Prometheus text format.
+func (m *metrics) writePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := make([]metricLabels, 0, len(m.series))
+	for l := range m.series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].provider != labels[j].provider {
+			return labels[i].provider < labels[j].provider
+		}
+		return labels[i].voice < labels[j].voice
+	})
+
+	counter := func(name, help string, value func(*metricSeries) int64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, l := range labels {
+			fmt.Fprintf(w, "%s{%s} %d\n", name, promLabels(l), value(m.series[l]))
+		}
+	}
+	counter("gospeak_requests_total", "Synthesis requests.", func(s *metricSeries) int64 { return s.requests })
+	counter("gospeak_request_errors_total", "Failed synthesis requests.", func(s *metricSeries) int64 { return s.errors })
+	counter("gospeak_audio_bytes_total", "Audio bytes synthesized.", func(s *metricSeries) int64 { return s.bytes })
+	counter("gospeak_cache_hits_total", "Audio cache hits.", func(s *metricSeries) int64 { return s.cacheHits })
+	counter("gospeak_cache_misses_total", "Audio cache misses.", func(s *metricSeries) int64 { return s.cacheMisses })
+
+	name := "gospeak_request_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Synthesis request latency.\n# TYPE %s histogram\n", name, name)
+	for _, l := range labels {
+		s := m.series[l]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, promLabels(l), le, s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, promLabels(l), s.requests)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, promLabels(l), s.latencySum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, promLabels(l), s.requests)
+	}
+}
+
+func promLabels(l metricLabels) string {
+	esc := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return fmt.Sprintf(`provider="%s",voice="%s"`, esc.Replace(l.provider), esc.Replace(l.voice))
+}
+
+// metricsHandler serves the metrics at a Prometheus /metrics endpoint.
+func (m *metrics) metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.writePrometheus(w)
+	})
+}
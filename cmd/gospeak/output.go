@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/schappim/gospeak"
+)
+
+// cacheKey identifies a synthesis by everything that affects the audio, so
+// identical requests always map to the same key. Provider-specific
+// settings go in extra.
+func cacheKey(provider, model, voice string, speed float64, format, text string, extra ...string) string {
+	h := sha256.New()
+	parts := append([]string{provider, model, voice, strconv.FormatFloat(speed, 'f', -1, 64), format, text}, extra...)
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// outputNameData holds the fields available to --output-template.
+type outputNameData struct {
+	Hash      string
+	ShortHash string
+	Provider  string
+	Voice     string
+	Model     string
+	Ext       string
+	Date      string // today, as 2006-01-02
+}
+
+// renderOutputName expands an --output-template such as
+// "clips/{{.Hash}}.{{.Ext}}".
+func renderOutputName(tmpl string, data outputNameData) (string, error) {
+	t, err := template.New("output").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+	if b.Len() == 0 {
+		return "", fmt.Errorf("output template produced an empty filename")
+	}
+	return b.String(), nil
+}
+
+// Longest title mp3Tags takes from the text
+const maxTagTitle = 30
+
+// mp3Tags returns the ID3 tags for a saved MP3: the start of the text as
+// the title, the provider as the artist, and the voice and model as the
+// comment. A non-empty title or artist replaces the default.
+func mp3Tags(r speechRequest, title, artist string) gospeak.MP3Tags {
+	if title == "" {
+		title = strings.Join(strings.Fields(r.text), " ")
+		if runes := []rune(title); len(runes) > maxTagTitle {
+			title = strings.TrimSpace(string(runes[:maxTagTitle]))
+		}
+	}
+	if artist == "" {
+		artist = r.provider
+	}
+	comment := r.voice
+	if r.model != "" {
+		comment += "/" + r.model
+	}
+	return gospeak.MP3Tags{Title: title, Artist: artist, Comment: comment}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/schappim/gospeak"
+)
+
+// playQueue plays clips one after another through the shared audio
+// context, on a single goroutine, so the code producing them can
+// synthesize the next clip while the last one plays. Clips never overlap
+// and are played in the order they were added.
+type playQueue struct {
+	clips chan []byte
+	done  chan struct{}
+	err   error // set before done is closed
+}
+
+// newPlayQueue starts a queue that holds up to depth clips waiting to
+// play. Playing stops when ctx is cancelled.
+func newPlayQueue(ctx context.Context, depth int) *playQueue {
+	q := &playQueue{clips: make(chan []byte, depth), done: make(chan struct{})}
+	go func() {
+		defer close(q.done)
+		// Each clip follows the last with no pause; only the device's
+		// buffer waits to play out, once, at the end
+		for clip := range q.clips {
+			if err := gospeak.PlayQueued(ctx, clip); err != nil {
+				if ctx.Err() != nil {
+					q.err = ctx.Err()
+					return
+				}
+				// One clip the device can't play shouldn't silence the rest
+				fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+			}
+		}
+		if err := gospeak.Drain(ctx); err != nil {
+			q.err = err
+		}
+	}()
+	return q
+}
+
+// add queues clip to play after the clips before it. It blocks while the
+// queue is full, so producers stay at most depth clips ahead of playback,
+// and drops the clip if playing has stopped.
+func (q *playQueue) add(clip []byte) {
+	select {
+	case q.clips <- clip:
+	case <-q.done:
+	}
+}
+
+// wait closes the queue and waits for the clips in it to finish playing.
+// It returns ctx's error if playing was interrupted.
+func (q *playQueue) wait() error {
+	close(q.clips)
+	<-q.done
+	return q.err
+}
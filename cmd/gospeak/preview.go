@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// defaultPreviewWords is how much --preview speaks when not given a count.
+const defaultPreviewWords = 20
+
+// previewCount is --preview, the number of words to speak. It can be given
+// bare, like a boolean flag, for defaultPreviewWords, or with a count as
+// --preview N or --preview=N; previewArgs makes the first form work.
+type previewCount int
+
+func (p *previewCount) String() string {
+	return strconv.Itoa(int(*p))
+}
+
+func (p *previewCount) Set(value string) error {
+	switch value {
+	case "true":
+		*p = defaultPreviewWords
+		return nil
+	case "false":
+		*p = 0
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("must be a positive number of words")
+	}
+	*p = previewCount(n)
+	return nil
+}
+
+func (p *previewCount) IsBoolFlag() bool {
+	return true
+}
+
+// previewArgs rewrites --preview N in args as --preview=N. A boolean flag
+// never takes the next argument, so the flag package would otherwise stop
+// at N and read it, and everything after it, as the text. Arguments after
+// "--" are left alone.
+func previewArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if (arg == "--preview" || arg == "-preview") && i+1 < len(args) {
+			if _, err := strconv.Atoi(args[i+1]); err == nil {
+				arg += "=" + args[i+1]
+				i++
+			}
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// firstWords returns text up to the end of its nth word, keeping the
+// spacing and punctuation within it, and how many words text has in all.
+func firstWords(text string, n int) (string, int) {
+	words, end := 0, len(text)
+	inWord := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			inWord = false
+			continue
+		}
+		if !inWord {
+			inWord = true
+			words++
+			if words == n+1 {
+				end = i
+			}
+		}
+	}
+	if words <= n {
+		return text, words
+	}
+	return strings.TrimRightFunc(text[:end], unicode.IsSpace), words
+}
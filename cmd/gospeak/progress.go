@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// quiet turns off all progress output (--quiet).
+var quiet bool
+
+var spinnerFrames = []rune(`|/-\`)
+
+// received counts the response bytes downloaded from provider APIs.
+var received atomic.Int64
+
+// activeProgress is the progress line currently on screen, if any, so
+// that other messages can clear it before printing.
+var activeProgress atomic.Pointer[statusLine]
+
+// countingTransport tallies response body bytes into received.
+type countingTransport struct {
+	base http.RoundTripper
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		resp.Body = countingBody{resp.Body}
+	}
+	return resp, err
+}
+
+type countingBody struct {
+	io.ReadCloser
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	received.Add(int64(n))
+	return n, err
+}
+
+// showProgress shows a spinner with label, the time elapsed, and the
+// bytes downloaded so far, until the returned function is called. Nothing
+// is shown with --quiet or when stderr isn't a terminal.
+func showProgress(label string) (stop func()) {
+	status := newStatusLine()
+	if !status.enabled {
+		return func() {}
+	}
+	activeProgress.Store(status)
+
+	start := time.Now()
+	base := received.Load()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			msg := fmt.Sprintf("%c %s... %.1fs", spinnerFrames[frame%len(spinnerFrames)], label, time.Since(start).Seconds())
+			if n := received.Load() - base; n > 0 {
+				msg += ", " + formatSize(n)
+			}
+			status.set("%s", msg)
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		activeProgress.CompareAndSwap(status, nil)
+		status.clear()
+	}
+}
+
+// clearProgress removes the progress line, if one is showing, so that a
+// message can be printed on a line of its own. The spinner redraws it on
+// its next tick.
+func clearProgress() {
+	if s := activeProgress.Load(); s != nil {
+		s.clear()
+	}
+}
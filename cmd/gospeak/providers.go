@@ -0,0 +1,622 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/schappim/gospeak"
+)
+
+// providerNames lists the supported providers in their default order.
+var providerNames = []string{"openai", "elevenlabs", "deepgram", "google", "polly", "azure", "playht", "watson", "lmnt", "resemble", "piper", "say", "espeak"}
+
+// Environment variables holding each provider's API key. Piper, say,
+// and espeak run locally and have none.
+var providerEnvVars = map[string]string{
+	"openai":     "OPENAI_API_KEY",
+	"elevenlabs": "ELEVENLABS_API_KEY",
+	"deepgram":   "DEEPGRAM_API_KEY",
+	"google":     "GOOGLE_API_KEY",
+	"polly":      "AWS_ACCESS_KEY_ID",
+	"azure":      "AZURE_SPEECH_KEY",
+	"playht":     "PLAYHT_API_KEY",
+	"watson":     "WATSON_API_KEY",
+	"lmnt":       "LMNT_API_KEY",
+	"resemble":   "RESEMBLE_API_KEY",
+}
+
+// Environment variables that override a provider's built-in voice and
+// model. An empty name means the provider has no such setting: Deepgram,
+// Google, Azure, LMNT, and Resemble voices imply their model, and Piper's
+// model is the voice.
+var providerDefaultEnvVars = map[string]struct{ voice, model string }{
+	"openai":     {"OPENAI_TTS_VOICE", "OPENAI_TTS_MODEL"},
+	"elevenlabs": {"ELEVENLABS_VOICE", "ELEVENLABS_MODEL"},
+	"deepgram":   {"DEEPGRAM_VOICE", ""},
+	"google":     {"GOOGLE_TTS_VOICE", ""},
+	"polly":      {"POLLY_VOICE", "POLLY_ENGINE"},
+	"azure":      {"AZURE_SPEECH_VOICE", ""},
+	"playht":     {"PLAYHT_VOICE", "PLAYHT_ENGINE"},
+	"watson":     {"WATSON_VOICE", ""},
+	"lmnt":       {"LMNT_VOICE", ""},
+	"resemble":   {"RESEMBLE_VOICE", ""},
+	"piper":      {"", "PIPER_MODEL"},
+	"say":        {"SAY_VOICE", ""},
+	"espeak":     {"ESPEAK_VOICE", ""},
+}
+
+func isValidProvider(provider string) bool {
+	for _, p := range providerNames {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// providerChoices formats the provider names for error messages:
+// "'openai', 'elevenlabs', or 'google'".
+func providerChoices() string {
+	quoted := make([]string, len(providerNames))
+	for i, p := range providerNames {
+		quoted[i] = "'" + p + "'"
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
+
+// apiKeyFor returns the API key for provider: token if given, otherwise
+// the provider's environment variable.
+func apiKeyFor(provider, token string) string {
+	if token != "" {
+		return token
+	}
+	return os.Getenv(providerEnvVars[provider])
+}
+
+// readTokenFile reads the API key named by --token-file: a file whose
+// contents, trimmed of surrounding whitespace, are the key, or on macOS a
+// "keychain:service/account" reference to a generic password in the
+// login keychain.
+func readTokenFile(ref string) (string, error) {
+	var key []byte
+	if rest, ok := strings.CutPrefix(ref, "keychain:"); ok {
+		service, account, ok := strings.Cut(rest, "/")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("invalid keychain reference '%s'. Use keychain:service/account", ref)
+		}
+		if runtime.GOOS != "darwin" {
+			return "", errors.New("keychain references need macOS; use a file path")
+		}
+		out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+		if err != nil {
+			return "", fmt.Errorf("no keychain item for service '%s' and account '%s'", service, account)
+		}
+		key = out
+	} else {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+		key = data
+	}
+	if k := strings.TrimSpace(string(key)); k != "" {
+		return k, nil
+	}
+	return "", fmt.Errorf("%s is empty", ref)
+}
+
+// needsAPIKey reports whether the provider is a cloud API that needs a
+// key, rather than a local program.
+func needsAPIKey(provider string) bool {
+	return provider != "piper" && provider != "say" && provider != "espeak"
+}
+
+// defaultVoiceFor returns the voice to use when none is given: the
+// provider's voice environment variable if set, otherwise its built-in
+// default.
+func defaultVoiceFor(provider string) string {
+	if name := providerDefaultEnvVars[provider].voice; name != "" && os.Getenv(name) != "" {
+		return os.Getenv(name)
+	}
+	return builtinVoiceFor(provider)
+}
+
+func builtinVoiceFor(provider string) string {
+	switch provider {
+	case "openai":
+		return gospeak.DefaultOpenAIVoice
+	case "elevenlabs":
+		return gospeak.DefaultElevenLabsVoice
+	case "deepgram":
+		return gospeak.DefaultDeepgramVoice
+	case "google":
+		return gospeak.DefaultGoogleVoice
+	case "polly":
+		return gospeak.DefaultPollyVoice
+	case "azure":
+		return gospeak.DefaultAzureVoice
+	case "playht":
+		return gospeak.DefaultPlayHTVoice
+	case "watson":
+		return gospeak.DefaultWatsonVoice
+	case "lmnt":
+		return gospeak.DefaultLMNTVoice
+	case "espeak":
+		return gospeak.DefaultEspeakVoice
+	}
+	return ""
+}
+
+// defaultModelFor is defaultVoiceFor for the model.
+func defaultModelFor(provider string) string {
+	if name := providerDefaultEnvVars[provider].model; name != "" && os.Getenv(name) != "" {
+		return os.Getenv(name)
+	}
+	return builtinModelFor(provider)
+}
+
+func builtinModelFor(provider string) string {
+	switch provider {
+	case "openai":
+		return gospeak.DefaultOpenAIModel
+	case "elevenlabs":
+		return gospeak.DefaultElevenLabsModel
+	case "polly":
+		// Polly's engine plays the role of the model
+		return gospeak.DefaultPollyEngine
+	case "playht":
+		// So is Play.ht's voice engine
+		return gospeak.DefaultPlayHTEngine
+	}
+	// Deepgram uses voice as model; Google, Azure, Watson, LMNT, and
+	// Resemble voices imply their model
+	return ""
+}
+
+// deepgramPresets returns the Deepgram preset voice names, sorted.
+func deepgramPresets() []string {
+	return slices.Sorted(maps.Keys(gospeak.DeepgramVoices))
+}
+
+// supportsSpeed reports whether the provider can change speaking speed
+// itself.
+func supportsSpeed(provider string) bool {
+	return provider != "deepgram" && provider != "polly" && provider != "resemble"
+}
+
+// resamplesSpeed reports whether gospeak changes the provider's speed
+// after synthesis, by resampling, because the API can't.
+func resamplesSpeed(provider string) bool {
+	return provider == "deepgram"
+}
+
+// supportsSSML reports whether the provider interprets SSML markup.
+func supportsSSML(provider string) bool {
+	return provider == "elevenlabs" || provider == "google" || provider == "polly" || provider == "azure" || provider == "watson" || provider == "resemble" || provider == "espeak"
+}
+
+// supportsSeed reports whether the provider takes a seed for
+// reproducible output.
+func supportsSeed(provider string) bool {
+	return provider == "elevenlabs" || provider == "playht"
+}
+
+// speedLimits holds the speed range of each provider with speed control,
+// and the name its errors use.
+var speedLimits = map[string]struct {
+	name     string
+	min, max float64
+}{
+	"openai":     {"OpenAI", 0.25, 4.0},
+	"google":     {"Google", 0.25, 4.0},
+	"piper":      {"Piper", 0.25, 4.0},
+	"say":        {"say", 0.25, 4.0},
+	"espeak":     {"espeak", 0.5, 2.5},
+	"deepgram":   {"Deepgram", 0.25, 4.0},
+	"elevenlabs": {"ElevenLabs", 0.7, 1.2},
+	"azure":      {"Azure", 0.5, 2.0},
+	"playht":     {"Play.ht", 0.1, 5.0},
+	"watson":     {"Watson", 0.5, 2.0},
+	"lmnt":       {"LMNT", 0.25, 2.0},
+}
+
+// validateSpeed checks speed against the provider's supported range.
+// Providers without speed control accept any value.
+func validateSpeed(provider string, speed float64) error {
+	limits, ok := speedLimits[provider]
+	if ok && (speed < limits.min || speed > limits.max) {
+		return fmt.Errorf("Speed must be between %s and %s for %s", formatSpeed(limits.min), formatSpeed(limits.max), limits.name)
+	}
+	return nil
+}
+
+// formatSpeed formats a speed limit with one or two decimals, as in 4.0
+// or 0.25.
+func formatSpeed(speed float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.2f", speed), "0")
+}
+
+// parseProviderList parses a comma-separated list of provider names such
+// as "elevenlabs,openai,deepgram".
+func parseProviderList(s string) ([]string, error) {
+	var providers []string
+	seen := map[string]bool{}
+	for _, p := range strings.Split(s, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if !isValidProvider(p) {
+			return nil, fmt.Errorf("unknown provider '%s' in list (valid: %s)", p, strings.Join(providerNames, ", "))
+		}
+		if !seen[p] {
+			seen[p] = true
+			providers = append(providers, p)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("empty provider list")
+	}
+	return providers, nil
+}
+
+// speechRequest holds everything needed to synthesize one clip.
+type speechRequest struct {
+	provider        string
+	apiKey          string
+	model           string
+	voice           string
+	text            string
+	format          string
+	ssml            bool
+	speed           float64
+	stability       float64
+	similarityBoost float64
+	style           float64
+	speakerBoost    *bool
+	instructions    string
+	sampleRate      int
+	bitrate         int
+	language        string
+	seed            uint32
+}
+
+var (
+	// maxRetries is set by --retries.
+	maxRetries = 3
+	// retryMaxWait is set by --retry-max-wait and caps any single wait.
+	retryMaxWait = 30 * time.Second
+	// piperBin is the piper executable, set from --piper-bin or PIPER_BIN.
+	piperBin = gospeak.DefaultPiperBin
+	// resembleProject is the Resemble project UUID clips are saved to,
+	// set from --resemble-project or RESEMBLE_PROJECT. Empty saves them
+	// to none.
+	resembleProject string
+	// openAIBaseURL points the openai provider at a compatible server, set
+	// from --base-url or OPENAI_BASE_URL. Empty means OpenAI itself.
+	openAIBaseURL string
+	// httpClient is shared by every provider; nil uses the library's
+	// default client. Set from --proxy.
+	httpClient *http.Client
+)
+
+// httpOptions applies --proxy, --retries, --retry-max-wait, and the
+// --rps limit of provider, warning about each retry on stderr.
+func httpOptions(provider string) gospeak.HTTPOptions {
+	return gospeak.HTTPOptions{
+		Client:       httpClient,
+		MaxRetries:   maxRetries,
+		MaxRetryWait: retryMaxWait,
+		Limiter:      limiterFor(provider),
+		OnRetry: func(err error, delay time.Duration) {
+			clearProgress()
+			fmt.Fprintf(os.Stderr, "Warning: %v, retrying in %.1fs\n", err, delay.Seconds())
+		},
+	}
+}
+
+// pollyCredentials returns the AWS secret key and region for Polly. The
+// access key is the provider's API key, read from AWS_ACCESS_KEY_ID.
+func pollyCredentials() (secretKey, region string) {
+	region = os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = gospeak.DefaultPollyRegion
+	}
+	return os.Getenv("AWS_SECRET_ACCESS_KEY"), region
+}
+
+// azureRegion returns the region of the Azure Speech resource, read from
+// AZURE_SPEECH_REGION. The key is the provider's API key.
+func azureRegion() string {
+	return os.Getenv("AZURE_SPEECH_REGION")
+}
+
+// watsonURL returns the URL of the Watson Text to Speech service
+// instance, read from WATSON_URL.
+func watsonURL() string {
+	return os.Getenv("WATSON_URL")
+}
+
+// playHTUserID returns the Play.ht user ID that the API key belongs to,
+// read from PLAYHT_USER_ID.
+func playHTUserID() string {
+	return os.Getenv("PLAYHT_USER_ID")
+}
+
+// resolvePiperBin picks the piper executable: the flag if given, then the
+// PIPER_BIN environment variable, then "piper" on the PATH.
+func resolvePiperBin(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("PIPER_BIN"); env != "" {
+		return env
+	}
+	return gospeak.DefaultPiperBin
+}
+
+// resolveResembleProject picks the Resemble project: the flag if given,
+// then the RESEMBLE_PROJECT environment variable.
+func resolveResembleProject(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("RESEMBLE_PROJECT")
+}
+
+// parseProxy checks a --proxy URL. Schemes other than http, https, and
+// socks5 are rejected, as is a URL without a host.
+func parseProxy(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --proxy URL '%s': %v", s, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("Invalid --proxy URL '%s'. Use an http://, https://, or socks5:// URL", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("Invalid --proxy URL '%s': missing host", s)
+	}
+	return u, nil
+}
+
+// resolveOpenAIBaseURL picks the OpenAI-compatible endpoint: the flag if
+// given, then the OPENAI_BASE_URL environment variable.
+func resolveOpenAIBaseURL(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("OPENAI_BASE_URL")
+}
+
+// newProvider builds the library provider for r.
+func newProvider(r speechRequest) (gospeak.Provider, error) {
+	switch r.provider {
+	case "openai":
+		return gospeak.OpenAIProvider{
+			APIKey:       r.apiKey,
+			BaseURL:      openAIBaseURL,
+			Instructions: r.instructions,
+			HTTPOptions:  httpOptions(r.provider),
+		}, nil
+	case "elevenlabs":
+		return gospeak.ElevenLabsProvider{
+			APIKey:          r.apiKey,
+			Stability:       r.stability,
+			SimilarityBoost: r.similarityBoost,
+			Style:           r.style,
+			SpeakerBoost:    r.speakerBoost,
+			Language:        r.language,
+			SampleRate:      r.sampleRate,
+			Bitrate:         r.bitrate,
+			HTTPOptions:     httpOptions(r.provider),
+		}, nil
+	case "deepgram":
+		return gospeak.DeepgramProvider{APIKey: r.apiKey, SampleRate: r.sampleRate, HTTPOptions: httpOptions(r.provider)}, nil
+	case "google":
+		return gospeak.GoogleProvider{APIKey: r.apiKey, HTTPOptions: httpOptions(r.provider)}, nil
+	case "polly":
+		secretKey, region := pollyCredentials()
+		return gospeak.PollyProvider{
+			AccessKey:    r.apiKey,
+			SecretKey:    secretKey,
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			Region:       region,
+			HTTPOptions:  httpOptions(r.provider),
+		}, nil
+	case "azure":
+		return gospeak.AzureProvider{APIKey: r.apiKey, Region: azureRegion(), HTTPOptions: httpOptions(r.provider)}, nil
+	case "playht":
+		return gospeak.PlayHTProvider{APIKey: r.apiKey, UserID: playHTUserID(), HTTPOptions: httpOptions(r.provider)}, nil
+	case "watson":
+		return gospeak.WatsonProvider{APIKey: r.apiKey, URL: watsonURL(), HTTPOptions: httpOptions(r.provider)}, nil
+	case "lmnt":
+		return gospeak.LMNTProvider{APIKey: r.apiKey, SampleRate: r.sampleRate, HTTPOptions: httpOptions(r.provider)}, nil
+	case "resemble":
+		return gospeak.ResembleProvider{APIKey: r.apiKey, ProjectUUID: resembleProject, HTTPOptions: httpOptions(r.provider)}, nil
+	case "piper":
+		return gospeak.PiperProvider{Bin: piperBin}, nil
+	case "say":
+		return gospeak.SayProvider{}, nil
+	case "espeak":
+		return gospeak.EspeakProvider{}, nil
+	}
+	return nil, fmt.Errorf("unknown provider '%s'", r.provider)
+}
+
+// libraryRequest converts r to the library's request type.
+func (r speechRequest) libraryRequest() gospeak.Request {
+	return gospeak.Request{
+		Text:   r.text,
+		Voice:  r.voice,
+		Model:  r.model,
+		Format: r.format,
+		Speed:  r.speed,
+		SSML:   r.ssml,
+		Seed:   r.seed,
+	}
+}
+
+// meteredProvider records every request it makes in appMetrics.
+type meteredProvider struct {
+	gospeak.Provider
+	name string
+}
+
+func (p meteredProvider) Synthesize(ctx context.Context, req gospeak.Request) ([]byte, error) {
+	start := time.Now()
+	audioData, err := p.Provider.Synthesize(ctx, req)
+	appMetrics.observeRequest(p.name, req.Voice, time.Since(start), len(audioData), err)
+	return audioData, err
+}
+
+// maxCharsFor returns the provider's per-request character limit, or zero
+// if it has none.
+func maxCharsFor(provider string) int {
+	switch provider {
+	case "openai":
+		return gospeak.OpenAIMaxChars
+	case "elevenlabs":
+		return gospeak.ElevenLabsMaxChars
+	case "deepgram":
+		return gospeak.DeepgramMaxChars
+	case "google":
+		return gospeak.GoogleMaxChars
+	case "polly":
+		return gospeak.PollyMaxChars
+	case "azure":
+		return gospeak.AzureMaxChars
+	case "playht":
+		return gospeak.PlayHTMaxChars
+	case "watson":
+		return gospeak.WatsonMaxChars
+	case "lmnt":
+		return gospeak.LMNTMaxChars
+	case "resemble":
+		return gospeak.ResembleMaxChars
+	}
+	return 0
+}
+
+// synthesize dispatches r to its provider, recording metrics when they are
+// enabled. Cancelling ctx aborts the request. Text over the provider's
+// limit is synthesized in chunks and joined.
+func synthesize(ctx context.Context, r speechRequest) ([]byte, error) {
+	p, err := newProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	metered := meteredProvider{Provider: p, name: r.provider}
+	audioData, err := gospeak.SynthesizeLong(ctx, metered, r.libraryRequest(), maxCharsFor(r.provider))
+	return audioData, shortAudioHint(r.provider, err)
+}
+
+// shortAudioHint names the provider in an ErrShortAudio, which on its own
+// doesn't say whose API sent the empty body.
+func shortAudioHint(provider string, err error) error {
+	if errors.Is(err, gospeak.ErrShortAudio) {
+		return fmt.Errorf("%s: %w; check the account's quota", provider, err)
+	}
+	return err
+}
+
+// synthesizeStream starts synthesis of r and returns the audio as it
+// downloads. The body is prefetched in the background, so slow playback
+// never stalls the download into the HTTP client timeout. Chunked text,
+// and providers that can't stream, are synthesized in full first.
+func synthesizeStream(ctx context.Context, r speechRequest) (io.ReadCloser, error) {
+	p, err := newProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(gospeak.StreamProvider)
+	if !ok || (!r.ssml && len(gospeak.SplitText(r.text, maxCharsFor(r.provider))) > 1) {
+		audioData, err := synthesize(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(audioData)), nil
+	}
+
+	start := time.Now()
+	body, err := sp.Stream(ctx, r.libraryRequest())
+	if err == nil {
+		// Catch an empty 200 now rather than as a decoder error mid-playback
+		body, err = gospeak.PeekAudio(body)
+	}
+	appMetrics.observeRequest(r.provider, r.voice, time.Since(start), 0, err)
+	if err != nil {
+		return nil, shortAudioHint(r.provider, err)
+	}
+	return prefetch(body), nil
+}
+
+// submitAsync submits r to Deepgram for --callback, one job per chunk of
+// long text, and returns the request IDs in order. Deepgram delivers the
+// audio to the callback URL, so nothing comes back to play.
+func submitAsync(ctx context.Context, r speechRequest, callback string) ([]string, error) {
+	p, err := newProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	dg, ok := p.(gospeak.DeepgramProvider)
+	if !ok {
+		return nil, errors.New("--callback needs the deepgram provider")
+	}
+
+	var ids []string
+	for _, chunk := range gospeak.SplitText(r.text, maxCharsFor(r.provider)) {
+		part := r
+		part.text = chunk
+		start := time.Now()
+		id, err := dg.SubmitAsync(ctx, part.libraryRequest(), callback)
+		appMetrics.observeRequest(r.provider, r.voice, time.Since(start), 0, err)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// synthesizeStreamInput is synthesizeStream over ElevenLabs' WebSocket
+// API, for --stream. The socket takes the text a sentence at a time, so
+// long text isn't synthesized in full first.
+func synthesizeStreamInput(ctx context.Context, r speechRequest) (io.ReadCloser, error) {
+	p, err := newProvider(r)
+	if err != nil {
+		return nil, err
+	}
+	el, ok := p.(gospeak.ElevenLabsProvider)
+	if !ok {
+		return nil, errors.New("--stream needs the elevenlabs provider")
+	}
+
+	start := time.Now()
+	body, err := el.StreamInput(ctx, r.libraryRequest())
+	if err == nil {
+		body, err = gospeak.PeekAudio(body)
+	}
+	appMetrics.observeRequest(r.provider, r.voice, time.Since(start), 0, err)
+	if err != nil {
+		return nil, shortAudioHint(r.provider, err)
+	}
+	return prefetch(body), nil
+}
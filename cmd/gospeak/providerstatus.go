@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/schappim/gospeak"
+)
+
+// providerStatus describes one provider for "gospeak providers": whether
+// it can be used as things stand, and what it offers.
+type providerStatus struct {
+	Name   string `json:"name"`
+	EnvVar string `json:"env_var,omitempty"`
+	KeySet bool   `json:"key_set"`
+	Ready  bool   `json:"ready"`
+	// Missing names what has to be set or installed before it is ready
+	Missing []string    `json:"missing,omitempty"`
+	Voice   string      `json:"default_voice,omitempty"`
+	Model   string      `json:"default_model,omitempty"`
+	Formats []string    `json:"formats"`
+	Speed   *speedRange `json:"speed,omitempty"`
+	SSML    bool        `json:"ssml"`
+}
+
+// speedRange is the speed a provider accepts. Resampled means gospeak
+// changes the speed itself, after synthesis.
+type speedRange struct {
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Resampled bool    `json:"resampled,omitempty"`
+}
+
+// runProvidersCommand implements "gospeak providers".
+func runProvidersCommand(args []string) error {
+	fs := flag.NewFlagSet("providers", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the providers as JSON")
+	fs.Parse(args)
+	return listProviders(*asJSON)
+}
+
+// listProviders prints the status of every provider, also used by
+// --list-providers. Keys are only checked for, never printed.
+func listProviders(asJSON bool) error {
+	statuses := make([]providerStatus, len(providerNames))
+	for i, p := range providerNames {
+		statuses[i] = statusOf(p)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tAPI KEY\tSTATUS\tVOICE\tMODEL\tFORMATS\tSPEED")
+	for _, s := range statuses {
+		key := "-"
+		if s.EnvVar != "" {
+			key = s.EnvVar + " (not set)"
+			if s.KeySet {
+				key = s.EnvVar + " (set)"
+			}
+		}
+		status := "ready"
+		if !s.Ready {
+			status = "needs " + strings.Join(s.Missing, ", ")
+		}
+		speed := "-"
+		if s.Speed != nil {
+			speed = formatSpeed(s.Speed.Min) + "-" + formatSpeed(s.Speed.Max)
+			if s.Speed.Resampled {
+				speed += " (resampled)"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, key, status, orDash(s.Voice), orDash(s.Model), strings.Join(s.Formats, ", "), speed)
+	}
+	return w.Flush()
+}
+
+// statusOf checks the environment for what provider p needs.
+func statusOf(p string) providerStatus {
+	s := providerStatus{
+		Name:    p,
+		EnvVar:  providerEnvVars[p],
+		KeySet:  apiKeyFor(p, "") != "",
+		Voice:   defaultVoiceFor(p),
+		Model:   defaultModelFor(p),
+		Formats: gospeak.Formats(p),
+		SSML:    supportsSSML(p),
+	}
+	if limits, ok := speedLimits[p]; ok {
+		s.Speed = &speedRange{Min: limits.min, Max: limits.max, Resampled: resamplesSpeed(p)}
+	}
+
+	// A self-hosted OpenAI-compatible server may not need a key
+	if needsAPIKey(p) && !s.KeySet && !(p == "openai" && resolveOpenAIBaseURL("") != "") {
+		s.Missing = append(s.Missing, s.EnvVar)
+	}
+	switch p {
+	case "polly":
+		if secretKey, _ := pollyCredentials(); secretKey == "" {
+			s.Missing = append(s.Missing, "AWS_SECRET_ACCESS_KEY")
+		}
+	case "azure":
+		if azureRegion() == "" {
+			s.Missing = append(s.Missing, "AZURE_SPEECH_REGION")
+		}
+	case "watson":
+		if watsonURL() == "" {
+			s.Missing = append(s.Missing, "WATSON_URL")
+		}
+	case "playht":
+		if playHTUserID() == "" {
+			s.Missing = append(s.Missing, "PLAYHT_USER_ID")
+		}
+	case "resemble":
+		if s.Voice == "" {
+			s.Missing = append(s.Missing, "RESEMBLE_VOICE")
+		}
+	case "piper":
+		if _, err := exec.LookPath(resolvePiperBin("")); err != nil {
+			s.Missing = append(s.Missing, "piper installed")
+		}
+		if s.Model == "" {
+			s.Missing = append(s.Missing, "PIPER_MODEL")
+		}
+	case "say":
+		if runtime.GOOS != "darwin" {
+			s.Missing = append(s.Missing, "macOS")
+		}
+	case "espeak":
+		if _, err := exec.LookPath(gospeak.DefaultEspeakBin); err != nil {
+			s.Missing = append(s.Missing, "espeak-ng installed")
+		}
+	}
+	s.Ready = len(s.Missing) == 0
+	return s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/schappim/gospeak"
+)
+
+// Default request rate allowed per provider, in requests per second.
+const defaultProviderRPS = 2.0
+
+var (
+	// providerRPS is set by --rps. Zero means no limit.
+	providerRPS = defaultProviderRPS
+
+	providerLimitersMu sync.Mutex
+	providerLimiters   = map[string]*gospeak.RateLimiter{}
+)
+
+// limiterFor returns the shared rate limiter for provider. It paces each
+// HTTP attempt, retries and the chunks of long text included, so a pool
+// of workers stays under the provider's rate limit instead of leaning on
+// retries. A burst of one spaces the requests out evenly.
+func limiterFor(provider string) *gospeak.RateLimiter {
+	providerLimitersMu.Lock()
+	defer providerLimitersMu.Unlock()
+	l, ok := providerLimiters[provider]
+	if !ok {
+		l = gospeak.NewRateLimiter(providerRPS, 1)
+		providerLimiters[provider] = l
+	}
+	return l
+}
+
+// synthesizeConcurrently runs reqs with at most maxConcurrent in flight,
+// each provider's requests paced by its rate limiter. Results and errors are
+// returned in the order of reqs. If onDone is non-nil it is called with
+// the number of finished requests after each one completes.
+func synthesizeConcurrently(ctx context.Context, reqs []speechRequest, maxConcurrent int, onDone func(done int)) ([][]byte, []error) {
+	wait := startSynthesis(ctx, reqs, maxConcurrent, onDone)
+	results := make([][]byte, len(reqs))
+	errs := make([]error, len(reqs))
+	for i := range reqs {
+		results[i], errs[i] = wait(i)
+	}
+	return results, errs
+}
+
+// startSynthesis starts synthesizing reqs on a pool of at most
+// maxConcurrent workers, each provider's requests paced by its rate
+// limiter. Requests are started in order, so the returned wait function,
+// which blocks until reqs[i] is finished, can be used to consume the
+// results in order while later ones are still in flight. onDone is as for
+// synthesizeConcurrently.
+func startSynthesis(ctx context.Context, reqs []speechRequest, maxConcurrent int, onDone func(done int)) (wait func(i int) ([]byte, error)) {
+	results := make([][]byte, len(reqs))
+	errs := make([]error, len(reqs))
+	finished := make([]chan struct{}, len(reqs))
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	next := make(chan int, len(reqs))
+	for i := range reqs {
+		next <- i
+	}
+	close(next)
+
+	var mu sync.Mutex
+	done := 0
+	for range min(max(maxConcurrent, 1), len(reqs)) {
+		go func() {
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+				} else {
+					results[i], errs[i] = synthesize(ctx, reqs[i])
+				}
+
+				if onDone != nil {
+					mu.Lock()
+					done++
+					onDone(done)
+					mu.Unlock()
+				}
+				close(finished[i])
+			}
+		}()
+	}
+
+	return func(i int) ([]byte, error) {
+		<-finished[i]
+		return results[i], errs[i]
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/schappim/gospeak"
+)
+
+const replHelp = `Type text to speak it, or a command:
+  :voice NAME   Switch to another voice
+  :speed N      Change the speaking speed
+  :help         Show this help
+  :quit         Exit (or press Ctrl+D)
+`
+
+// runREPL reads lines from stdin and speaks each one with base, until EOF
+// or :quit. prepare applies the same text rewriting as a normal run. Each
+// line goes through the cache and counts towards the monthly budget.
+// Ctrl+C stops the line being spoken; at the prompt it exits.
+func runREPL(base speechRequest, prepare func(string) (string, error), budget float64, useCache bool) {
+	usage := openUsage(budget)
+
+	interactive := isTerminal(os.Stdin)
+	if interactive {
+		fmt.Fprintf(os.Stderr, "Speaking with %s (%s). Type :help for commands.\n", base.provider, base.voice)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	r := base
+	for {
+		if interactive {
+			fmt.Fprint(os.Stderr, "> ")
+		}
+		if !scanner.Scan() {
+			if interactive {
+				fmt.Fprintln(os.Stderr)
+			}
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			if replCommand(&r, line) {
+				return
+			}
+			continue
+		}
+
+		text, err := prepare(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		r.text = text
+		speakLine(r, usage, budget, useCache)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+	}
+}
+
+// replCommand applies a :command to r, and reports whether it asks to
+// quit.
+func replCommand(r *speechRequest, line string) (quit bool) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "quit", "q", "exit":
+		return true
+	case "help":
+		fmt.Fprint(os.Stderr, replHelp)
+	case "voice":
+		if arg == "" {
+			fmt.Fprintf(os.Stderr, "Voice: %s\n", r.voice)
+			break
+		}
+		if voices, err := resolveVoices(r.provider, arg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			break
+		} else if len(voices) != 1 {
+			fmt.Fprintln(os.Stderr, "Error: :voice takes a single voice")
+			break
+		}
+		r.voice = arg
+		fmt.Fprintf(os.Stderr, "Voice: %s\n", r.voice)
+	case "speed":
+		if arg == "" {
+			fmt.Fprintf(os.Stderr, "Speed: %g\n", r.speed)
+			break
+		}
+		speed, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Invalid speed '%s'\n", arg)
+			break
+		}
+		if err := validateSpeed(r.provider, speed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			break
+		}
+		if r.provider == "polly" {
+			fmt.Fprintln(os.Stderr, "Warning: Speed adjustment is not supported for Polly, ignoring")
+			break
+		}
+		r.speed = speed
+		fmt.Fprintf(os.Stderr, "Speed: %g\n", r.speed)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown command ':%s'. Type :help for commands\n", name)
+	}
+	return false
+}
+
+// speakLine synthesizes and plays one REPL line. Failures are reported
+// and the REPL carries on.
+func speakLine(r speechRequest, usage *usageRecord, budget float64, useCache bool) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	audioData := synthesizeLine(ctx, r, usage, budget, useCache)
+	if audioData == nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+		}
+		return
+	}
+	if err := gospeak.Play(ctx, audioData); err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error playing audio: %v\n", err)
+	}
+}
+
+// synthesizeLine returns the audio for one line of --repl or --follow
+// input, from the cache or the provider, ready to play. Failures are
+// reported and nil is returned so the caller can go on to the next line;
+// an interruption is left to the caller to report.
+func synthesizeLine(ctx context.Context, r speechRequest, usage *usageRecord, budget float64, useCache bool) []byte {
+	audioData, cached := []byte(nil), false
+	if useCache {
+		audioData, cached = cacheLoad(r.cacheKey(), r.format)
+		appMetrics.observeCache(r.provider, r.voice, cached)
+	}
+	if !cached {
+		chars := utf8.RuneCountInString(r.text)
+		if estimated := estimateCost(r.provider, r.model, chars); budget > 0 && usage.Spend+estimated > budget {
+			fmt.Fprintf(os.Stderr, "Error: Monthly budget of $%.2f would be exceeded (spent $%.2f, this line ~$%.4f)\n", budget, usage.Spend, estimated)
+			return nil
+		}
+		stopProgress := showProgress("Synthesizing with " + r.provider)
+		var err error
+		audioData, err = synthesize(ctx, r)
+		stopProgress()
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "Error synthesizing speech: %v\n", err)
+			}
+			return nil
+		}
+		usage.add(r.provider, r.model, chars)
+		if err := usage.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save usage: %v\n", err)
+		}
+		if useCache {
+			if err := cacheStore(r.cacheKey(), r, audioData); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+			}
+		}
+	}
+
+	if resamplesSpeed(r.provider) && r.speed != defaultSpeed {
+		var err error
+		if audioData, err = gospeak.ChangeSpeed(audioData, r.speed); err != nil {
+			fmt.Fprintf(os.Stderr, "Error changing speed: %v\n", err)
+			return nil
+		}
+	}
+	return audioData
+}
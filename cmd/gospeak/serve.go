@@ -0,0 +1,220 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/schappim/gospeak"
+)
+
+// Largest /speak request body accepted
+const maxSpeakBody = 1 << 20
+
+// Content-Type of each audio format served by /speak
+var audioContentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"wav":  "audio/wav",
+	"opus": "audio/ogg",
+	"flac": "audio/flac",
+	"pcm":  "application/octet-stream",
+}
+
+// speakRequest is the JSON body of POST /speak. Only text is required;
+// the rest default as on the command line.
+type speakRequest struct {
+	Provider string  `json:"provider"`
+	Voice    string  `json:"voice"`
+	Model    string  `json:"model"`
+	Format   string  `json:"format"`
+	Text     string  `json:"text"`
+	Speed    float64 `json:"speed"`
+}
+
+// runServeCommand implements "gospeak serve": an HTTP server with POST
+// /speak, which answers with the synthesized audio, GET /voices for the
+// built-in presets, and GET /metrics for Prometheus. API keys come from
+// the server's environment, as for the main command.
+func runServeCommand(args []string) error {
+	cfg, err := loadConfig(configPathFromArgs(args))
+	if err != nil {
+		return err
+	}
+	def := defaultProvider
+	if p, source := providerSetting(cfg, ""); p != "" {
+		if !isValidProvider(strings.ToLower(p)) {
+			return fmt.Errorf("Invalid provider '%s' from %s. Use %s", p, source, providerChoices())
+		}
+		def = strings.ToLower(p)
+	}
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	fs.String("config", "", "Read defaults from this config file")
+	noCache := fs.Bool("no-cache", false, "Always call the provider instead of reusing cached audio")
+	fs.BoolVar(&quiet, "quiet", false, "Don't log requests")
+	fs.Parse(args)
+
+	appMetrics = newMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /speak", speakHandler(def, !*noCache))
+	mux.HandleFunc("GET /voices", voicesHandler)
+	mux.Handle("GET /metrics", appMetrics.metricsHandler())
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	// Finish the requests in flight on Ctrl+C or SIGTERM
+	ctx := interruptContext()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *addr)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// speakHandler serves POST /speak, synthesizing with defProvider unless
+// the request names another.
+func speakHandler(defProvider string, useCache bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		var body speakRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSpeakBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			httpError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+		req, err := body.speechRequest(defProvider)
+		if err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var audioData []byte
+		cached := false
+		if useCache {
+			audioData, cached = cacheLoad(req.cacheKey(), req.format)
+			appMetrics.observeCache(req.provider, req.voice, cached)
+		}
+		if !cached {
+			if audioData, err = synthesize(r.Context(), req); err != nil {
+				status := http.StatusBadGateway
+				if r.Context().Err() != nil {
+					status = http.StatusServiceUnavailable
+				}
+				logRequest(r, req, status, start)
+				httpError(w, status, err)
+				return
+			}
+			if useCache {
+				if err := cacheStore(req.cacheKey(), req, audioData); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+				}
+			}
+		}
+
+		// Deepgram can't change speed itself, so resample as the CLI does
+		format := req.format
+		if resamplesSpeed(req.provider) && req.speed != defaultSpeed {
+			if audioData, err = gospeak.ChangeSpeed(audioData, req.speed); err != nil {
+				httpError(w, http.StatusInternalServerError, fmt.Errorf("failed to change speed: %v", err))
+				return
+			}
+			format = "wav"
+		}
+		w.Header().Set("Content-Type", audioContentTypes[format])
+		w.Header().Set("Content-Length", fmt.Sprint(len(audioData)))
+		w.Write(audioData)
+		logRequest(r, req, http.StatusOK, start)
+	}
+}
+
+// speechRequest validates a /speak body and fills in the defaults.
+func (b speakRequest) speechRequest(defProvider string) (speechRequest, error) {
+	p := strings.ToLower(cmp.Or(b.Provider, defProvider))
+	if !isValidProvider(p) {
+		return speechRequest{}, fmt.Errorf("Invalid provider '%s'. Use %s", p, providerChoices())
+	}
+	if strings.TrimSpace(b.Text) == "" {
+		return speechRequest{}, errors.New("text is required")
+	}
+	key := apiKeyFor(p, "")
+	if needsAPIKey(p) && key == "" {
+		return speechRequest{}, fmt.Errorf("%s is not set on the server", providerEnvVars[p])
+	}
+	format := cmp.Or(b.Format, gospeak.DefaultFormat(p))
+	if err := gospeak.ValidateFormat(p, format); err != nil {
+		return speechRequest{}, err
+	}
+	speed := b.Speed
+	if speed == 0 {
+		speed = defaultSpeed
+	}
+	if err := validateSpeed(p, speed); err != nil {
+		return speechRequest{}, err
+	}
+	if resamplesSpeed(p) && speed != defaultSpeed && !gospeak.IsPlayableFormat(format) {
+		return speechRequest{}, fmt.Errorf("%s changes speed by resampling, which needs mp3, wav, or opus audio", p)
+	}
+	return speechRequest{
+		provider:        p,
+		apiKey:          key,
+		model:           cmp.Or(b.Model, defaultModelFor(p)),
+		voice:           cmp.Or(b.Voice, defaultVoiceFor(p)),
+		text:            b.Text,
+		format:          format,
+		speed:           speed,
+		stability:       gospeak.DefaultElevenLabsStability,
+		similarityBoost: gospeak.DefaultElevenLabsSimilarity,
+	}, nil
+}
+
+// voicesHandler serves GET /voices: the built-in presets of every
+// provider, or of the one named by ?provider=.
+func voicesHandler(w http.ResponseWriter, r *http.Request) {
+	voices := map[string][]gospeak.Voice{}
+	for _, p := range providerNames {
+		if presets := gospeak.BuiltinVoices(p); len(presets) > 0 {
+			voices[p] = presets
+		}
+	}
+	if p := strings.ToLower(r.URL.Query().Get("provider")); p != "" {
+		if !isValidProvider(p) {
+			httpError(w, http.StatusBadRequest, fmt.Errorf("Invalid provider '%s'. Use %s", p, providerChoices()))
+			return
+		}
+		voices = map[string][]gospeak.Voice{p: voices[p]}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(voices)
+}
+
+// httpError answers with err as a JSON {"error": ...} document.
+func httpError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// logRequest logs a /speak request on stderr unless --quiet is set.
+func logRequest(r *http.Request, req speechRequest, status int, start time.Time) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %s %s/%s %d chars: %d in %s\n", r.Method, r.URL.Path, req.provider, req.voice,
+		len([]rune(req.text)), status, time.Since(start).Round(time.Millisecond))
+}
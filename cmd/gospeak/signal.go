@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context that is cancelled on the first
+// Ctrl+C or SIGTERM, so in-flight requests return promptly instead of
+// waiting out the HTTP timeout, and playback stops with the player
+// closed. After that the default handling is restored, and a second
+// Ctrl+C kills the process outright.
+func interruptContext() context.Context {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx
+}
+
+// exitIfInterrupted exits with the conventional SIGINT status when a
+// request or playback failed because ctx was cancelled. A killed piper process
+// doesn't report context.Canceled, so any failure after cancellation
+// counts.
+func exitIfInterrupted(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		removeTempFiles()
+		// Skip the error tone: the user asked for silence
+		os.Exit(130)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/schappim/gospeak"
+)
+
+// srtCue is one subtitle: a sentence and when it is spoken.
+type srtCue struct {
+	Start, End time.Duration
+	Text       string
+}
+
+// sentenceCues makes a cue for each sentence of text, timed from the
+// word timings. Words are matched to sentences in order, so timings from
+// estimateWordTimings or alignedWordTimings, which both split at
+// whitespace, line up with the sentences' words.
+func sentenceCues(text string, words []wordTiming) []srtCue {
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+	}
+
+	var cues []srtCue
+	next := 0
+	for _, sentence := range gospeak.SplitSentences(text) {
+		if next >= len(words) {
+			break
+		}
+		fields := strings.Fields(sentence)
+		last := min(next+len(fields), len(words)) - 1
+		cues = append(cues, srtCue{
+			Start: seconds(words[next].Start),
+			End:   seconds(words[last].End),
+			// A blank line would end the cue early
+			Text: strings.Join(fields, " "),
+		})
+		next = last + 1
+	}
+	// Alignment may split the text into more words than it has; give any
+	// left over to the last cue
+	if len(cues) > 0 && next < len(words) {
+		cues[len(cues)-1].End = seconds(words[len(words)-1].End)
+	}
+	return cues
+}
+
+// formatSRT renders cues as a SubRip subtitle file.
+func formatSRT(cues []srtCue) string {
+	stamp := func(d time.Duration) string {
+		ms := d.Milliseconds()
+		return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+	}
+	var b strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, stamp(c.Start), stamp(c.End), c.Text)
+	}
+	return b.String()
+}
+
+// writeSRT saves cues as an SRT file.
+func writeSRT(path string, cues []srtCue) error {
+	return writeFileAtomic(path, []byte(formatSRT(cues)), 0644)
+}
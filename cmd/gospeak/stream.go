@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefetchReader buffers a response body in memory as fast as the network
+// delivers it, while a consumer reads from the front at its own pace.
+type prefetchReader struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	err    error
+	closed bool
+	body   io.ReadCloser
+}
+
+// prefetch starts downloading body in the background and returns a reader
+// over the downloaded data.
+func prefetch(body io.ReadCloser) io.ReadCloser {
+	p := &prefetchReader{body: body}
+	p.cond = sync.NewCond(&p.mu)
+	go p.fill()
+	return p
+}
+
+func (p *prefetchReader) fill() {
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := p.body.Read(chunk)
+		p.mu.Lock()
+		p.buf.Write(chunk[:n])
+		if err != nil {
+			p.err = err
+		}
+		done := p.err != nil || p.closed
+		p.cond.Broadcast()
+		p.mu.Unlock()
+		if done {
+			return
+		}
+	}
+}
+
+func (p *prefetchReader) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.buf.Len() == 0 && p.err == nil && !p.closed {
+		p.cond.Wait()
+	}
+	if p.buf.Len() > 0 {
+		return p.buf.Read(b)
+	}
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return 0, p.err
+}
+
+func (p *prefetchReader) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	return p.body.Close()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Width used when the terminal size can't be determined.
+const fallbackTermWidth = 80
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// terminalWidth returns the width of the terminal attached to f, or
+// fallbackTermWidth when it can't be determined (e.g. piped output).
+func terminalWidth(f *os.File) int {
+	w, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || w <= 0 {
+		return fallbackTermWidth
+	}
+	return w
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from
+// stdin. Anything but y or yes counts as no.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// statusLine shows a single, continually updated line of progress on
+// stderr. When stderr isn't a terminal, or with --quiet, it prints
+// nothing, so no control characters leak into logs or CI output.
+type statusLine struct {
+	mu      sync.Mutex
+	enabled bool
+	shown   bool
+}
+
+func newStatusLine() *statusLine {
+	return &statusLine{enabled: !quiet && isTerminal(os.Stderr)}
+}
+
+// set replaces the status text, truncated to fit the terminal width.
+func (s *statusLine) set(format string, args ...any) {
+	if !s.enabled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := []rune(fmt.Sprintf(format, args...))
+	if width := terminalWidth(os.Stderr) - 1; len(msg) > width {
+		msg = msg[:max(width, 0)]
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K%s", string(msg))
+	s.shown = true
+}
+
+// clear removes the status line so normal output can follow.
+func (s *statusLine) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shown {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		s.shown = false
+	}
+}
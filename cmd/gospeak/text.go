@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// Valid values for --caps-as
+var capsModes = []string{"normal", "emphasis", "spell"}
+
+// isAllCaps reports whether word has at least two letters, all upper case.
+func isAllCaps(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 2
+}
+
+// applyCapsMode rewrites fully-capitalized words so they are read the same
+// way by every provider:
+//
+//	normal   leave as-is
+//	emphasis read as ordinary words set off by short pauses
+//	spell    spell out letter by letter (good for acronyms)
+//
+// In emphasis mode, runs of consecutive capitalized words are treated as one
+// phrase.
+func applyCapsMode(text, mode string) (string, error) {
+	switch mode {
+	case "", "normal":
+		return text, nil
+	case "emphasis", "spell":
+	default:
+		return "", fmt.Errorf("invalid --caps-as '%s'. Use %s", mode, strings.Join(capsModes, ", "))
+	}
+
+	var b strings.Builder
+	last := 0
+	words := wordPattern.FindAllStringIndex(text, -1)
+	for i := 0; i < len(words); i++ {
+		start, end := words[i][0], words[i][1]
+		if !isAllCaps(text[start:end]) {
+			continue
+		}
+
+		if mode == "spell" {
+			b.WriteString(text[last:start])
+			b.WriteString(spellOut(text[start:end]))
+			last = end
+			continue
+		}
+
+		// Extend over following capitalized words separated by spaces
+		for i+1 < len(words) && isAllCaps(text[words[i+1][0]:words[i+1][1]]) &&
+			strings.TrimSpace(text[end:words[i+1][0]]) == "" {
+			i++
+			end = words[i][1]
+		}
+		b.WriteString(strings.TrimRight(text[last:start], " "))
+		if s := b.String(); s != "" {
+			if r := []rune(s); unicode.IsPunct(r[len(r)-1]) {
+				b.WriteString(" ")
+			} else {
+				b.WriteString(", ")
+			}
+		}
+		b.WriteString(strings.ToLower(text[start:end]))
+		if end < len(text) && !unicode.IsPunct(rune(text[end])) {
+			b.WriteString(",")
+		}
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String(), nil
+}
+
+// spellOut separates the characters of word with spaces: "NASA" -> "N A S A".
+func spellOut(word string) string {
+	return strings.Join(strings.Split(word, ""), " ")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/schappim/gospeak"
+)
+
+// wordTiming is the start and end of a spoken word, in seconds.
+type wordTiming struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// charTiming is the start and end of a spoken character, in seconds.
+type charTiming struct {
+	Char  string  `json:"char"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// timingsFile is the JSON document written by --timestamps and
+// --timestamps-estimate. Only provider alignment has per-character times.
+type timingsFile struct {
+	Duration   float64      `json:"duration"`
+	Estimated  bool         `json:"estimated"`
+	Words      []wordTiming `json:"words"`
+	Characters []charTiming `json:"characters,omitempty"`
+}
+
+// estimateWordTimings spreads duration across the words of text, weighted
+// by an estimated syllable count. None of the providers return alignment
+// data from their plain synthesis endpoints, so this gives approximate
+// captions for any of them; --timestamps asks ElevenLabs for the real
+// thing.
+func estimateWordTimings(text string, duration time.Duration) []wordTiming {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	weights := make([]int, len(words))
+	total := 0
+	for i, w := range words {
+		weights[i] = countSyllables(w)
+		total += weights[i]
+	}
+
+	timings := make([]wordTiming, len(words))
+	perUnit := duration.Seconds() / float64(total)
+	elapsed := 0
+	for i, w := range words {
+		timings[i] = wordTiming{
+			Word:  w,
+			Start: float64(elapsed) * perUnit,
+			End:   float64(elapsed+weights[i]) * perUnit,
+		}
+		elapsed += weights[i]
+	}
+	return timings
+}
+
+// countSyllables estimates the syllables in word by counting vowel groups.
+// Words without vowels (numbers, symbols) are weighted by length instead.
+func countSyllables(word string) int {
+	count := 0
+	inVowel := false
+	letters := 0
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters++
+		}
+		isVowel := strings.ContainsRune("aeiouy", r)
+		if isVowel && !inVowel {
+			count++
+		}
+		inVowel = isVowel
+	}
+	if count == 0 {
+		count = (letters + 2) / 3
+	}
+	return max(count, 1)
+}
+
+// synthesizeAligned synthesizes r with ElevenLabs' timestamps endpoint,
+// for --timestamps, returning the audio and when each character is
+// spoken. Long text is synthesized in chunks as by synthesize, with each
+// chunk's times moved along by the length of the audio before it.
+func synthesizeAligned(ctx context.Context, r speechRequest) ([]byte, []charTiming, error) {
+	p, err := newProvider(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	el, ok := p.(gospeak.ElevenLabsProvider)
+	if !ok {
+		return nil, nil, errors.New("--timestamps needs the elevenlabs provider; use --timestamps-estimate with others")
+	}
+
+	chunks := []string{r.text}
+	if !r.ssml {
+		chunks = gospeak.SplitText(r.text, maxCharsFor(r.provider))
+	}
+	var clips [][]byte
+	var chars []charTiming
+	offset := 0.0
+	for i, chunk := range chunks {
+		part := r.libraryRequest()
+		part.Text = chunk
+		start := time.Now()
+		audioData, alignment, err := el.SynthesizeWithTimestamps(ctx, part)
+		appMetrics.observeRequest(r.provider, r.voice, time.Since(start), len(audioData), err)
+		if err != nil {
+			err = shortAudioHint(r.provider, err)
+			if len(chunks) > 1 {
+				err = fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			return nil, nil, err
+		}
+		if i > 0 {
+			// Chunks are trimmed, so put back a space to keep the words
+			// either side of the join apart
+			chars = append(chars, charTiming{Char: " ", Start: offset, End: offset})
+		}
+		for j, c := range alignment.Characters {
+			if j < len(alignment.StartTimes) && j < len(alignment.EndTimes) {
+				chars = append(chars, charTiming{Char: c, Start: offset + alignment.StartTimes[j], End: offset + alignment.EndTimes[j]})
+			}
+		}
+		// The clip may run on after the last character
+		length, err := gospeak.AudioDuration(audioData)
+		if err != nil || length == 0 {
+			if n := len(alignment.EndTimes); n > 0 {
+				length = time.Duration(alignment.EndTimes[n-1] * float64(time.Second))
+			}
+		}
+		offset += length.Seconds()
+		clips = append(clips, audioData)
+	}
+	if len(clips) == 1 {
+		return clips[0], chars, nil
+	}
+	audioData, err := gospeak.JoinAudio(clips, r.format)
+	return audioData, chars, err
+}
+
+// alignedWordTimings groups character timings into words at whitespace.
+func alignedWordTimings(chars []charTiming) []wordTiming {
+	var words []wordTiming
+	var cur *wordTiming
+	for _, c := range chars {
+		if strings.TrimSpace(c.Char) == "" {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			words = append(words, wordTiming{Start: c.Start})
+			cur = &words[len(words)-1]
+		}
+		cur.Word += c.Char
+		cur.End = c.End
+	}
+	return words
+}
+
+// writeTimings saves word timings as JSON. chars, from provider
+// alignment, is nil when the timings are estimated.
+func writeTimings(path string, timings []wordTiming, chars []charTiming, duration time.Duration) error {
+	data, err := json.MarshalIndent(timingsFile{
+		Duration:   duration.Seconds(),
+		Estimated:  chars == nil,
+		Words:      timings,
+		Characters: chars,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal timestamps: %w", err)
+	}
+	return writeFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/schappim/gospeak"
+)
+
+// runVoicesCommand implements "gospeak voices". With an API key it asks
+// the provider for the voices on the account; without one, or for
+// providers that have no list endpoint, it prints the built-in presets.
+func runVoicesCommand(args []string) error {
+	// Default to the provider from GOSPEAK_PROVIDER or the config file,
+	// as the main command does
+	cfg, err := loadConfig(configPathFromArgs(args))
+	if err != nil {
+		return err
+	}
+	def := defaultProvider
+	if p, source := providerSetting(cfg, ""); p != "" {
+		if !isValidProvider(strings.ToLower(p)) {
+			return fmt.Errorf("Invalid provider '%s' from %s. Use %s", p, source, providerChoices())
+		}
+		def = p
+	}
+
+	fs := flag.NewFlagSet("voices", flag.ExitOnError)
+	provider := fs.String("provider", def, "TTS provider")
+	fs.StringVar(provider, "p", def, "TTS provider (shorthand)")
+	fs.String("config", "", "Read defaults from this config file")
+	token := fs.String("token", "", "API key (overrides the provider's environment variable)")
+	asJSON := fs.Bool("json", false, "Print the voices as JSON")
+	fs.Parse(args)
+
+	p := strings.ToLower(*provider)
+	if !isValidProvider(p) {
+		return fmt.Errorf("Invalid provider '%s'. Use %s", p, providerChoices())
+	}
+	return listVoices(p, *token, *asJSON)
+}
+
+// listVoices prints the voices of provider p, also used by --list-voices.
+func listVoices(p, token string, asJSON bool) error {
+	if p == "piper" {
+		return errors.New("piper voices are .onnx model files; pass one with --model")
+	}
+	if p == "say" {
+		return errors.New("say uses the voices installed in macOS; run 'say -v ?' to list them")
+	}
+	if p == "espeak" {
+		return errors.New("espeak uses the voices installed with espeak-ng; run 'espeak-ng --voices' to list them")
+	}
+	if p == "resemble" && apiKeyFor(p, token) == "" {
+		return errors.New("resemble voices are UUIDs on your account; set RESEMBLE_API_KEY to list them")
+	}
+
+	voices := gospeak.BuiltinVoices(p)
+	source := "built-in presets"
+	if key := apiKeyFor(p, token); key != "" {
+		prov, err := newProvider(speechRequest{provider: p, apiKey: key})
+		if err != nil {
+			return err
+		}
+		if lister, ok := prov.(gospeak.VoiceLister); ok {
+			ctx := interruptContext()
+			fetched, err := lister.Voices(ctx)
+			if err != nil {
+				exitIfInterrupted(ctx, err)
+				return fmt.Errorf("failed to list %s voices: %w", p, err)
+			}
+			voices = mergeVoices(fetched, voices)
+			source = "from the " + p + " API"
+		}
+	} else if p == "elevenlabs" || p == "deepgram" || p == "playht" || p == "watson" || p == "lmnt" {
+		fmt.Fprintf(os.Stderr, "%s not set, showing built-in presets\n", providerEnvVars[p])
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(voices)
+	}
+
+	fmt.Printf("%s voices (%s):\n", p, source)
+	for _, v := range voices {
+		line := fmt.Sprintf("  %-24s %s", v.Name, v.ID)
+		if v.Description != "" {
+			line = fmt.Sprintf("%-60s %s", line, v.Description)
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// mergeVoices lists the fetched voices followed by any presets whose
+// voice they don't already include, so preset names stay discoverable.
+func mergeVoices(fetched, presets []gospeak.Voice) []gospeak.Voice {
+	have := map[string]bool{}
+	for _, v := range fetched {
+		have[v.ID] = true
+	}
+	for _, v := range presets {
+		if !have[v.ID] {
+			v.Description = "preset"
+			fetched = append(fetched, v)
+		}
+	}
+	return fetched
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/schappim/gospeak"
+)
+
+// calibrateSpeed finds the speed at which r's provider reads its text at
+// about wpm words per minute, for --wpm. The text is synthesized at
+// normal speed, from the cache if it can be, and timed; the speed is the
+// ratio of the target rate to the rate measured, limited to what the
+// provider accepts. That first clip is returned too, since a provider
+// whose speed gospeak changes by resampling needs nothing more.
+func calibrateSpeed(ctx context.Context, r speechRequest, wpm int, useCache bool, recordUsage func(provider, model string, n int)) (float64, []byte, error) {
+	words := len(strings.Fields(r.text))
+	if words == 0 {
+		return defaultSpeed, nil, nil
+	}
+
+	r.speed = defaultSpeed
+	audioData, cached := []byte(nil), false
+	if useCache {
+		audioData, cached = cacheLoad(r.cacheKey(), r.format)
+		appMetrics.observeCache(r.provider, r.voice, cached)
+	}
+	if !cached {
+		stop := showProgress("Timing " + r.provider + " at normal speed")
+		var err error
+		audioData, err = synthesize(ctx, r)
+		stop()
+		if err != nil {
+			return 0, nil, err
+		}
+		recordUsage(r.provider, r.model, utf8.RuneCountInString(r.text))
+		if useCache {
+			if err := cacheStore(r.cacheKey(), r, audioData); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to cache audio: %v\n", err)
+			}
+		}
+	}
+
+	duration, err := gospeak.AudioDuration(audioData)
+	if err != nil {
+		return 0, nil, err
+	}
+	if duration <= 0 {
+		return defaultSpeed, audioData, nil
+	}
+	rate := float64(words) / duration.Minutes()
+	speed := float64(wpm) / rate
+
+	limits := speedLimits[r.provider]
+	if speed < limits.min || speed > limits.max {
+		speed = max(limits.min, min(speed, limits.max))
+		fmt.Fprintf(os.Stderr, "Warning: %s reads this at about %.0f wpm and its speed is limited to %s-%s; speaking at %.0f wpm instead of %d\n",
+			limits.name, rate, formatSpeed(limits.min), formatSpeed(limits.max), rate*speed, wpm)
+	}
+	return speed, audioData, nil
+}
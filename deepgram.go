@@ -0,0 +1,180 @@
+package gospeak
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Deepgram defaults
+	DefaultDeepgramVoice = "aura-asteria-en"
+	DeepgramMaxChars     = 2000
+	deepgramAPIURL       = "https://api.deepgram.com/v1/speak"
+	deepgramModelsURL    = "https://api.deepgram.com/v1/models"
+)
+
+// Deepgram voice presets (short name -> full model name)
+var DeepgramVoices = map[string]string{
+	// Aura voices (English)
+	"asteria": "aura-asteria-en",
+	"luna":    "aura-luna-en",
+	"stella":  "aura-stella-en",
+	"athena":  "aura-athena-en",
+	"hera":    "aura-hera-en",
+	"orion":   "aura-orion-en",
+	"arcas":   "aura-arcas-en",
+	"perseus": "aura-perseus-en",
+	"angus":   "aura-angus-en",
+	"orpheus": "aura-orpheus-en",
+	"helios":  "aura-helios-en",
+	"zeus":    "aura-zeus-en",
+	// Aura 2 voices (English)
+	"thalia":    "aura-2-thalia-en",
+	"andromeda": "aura-2-andromeda-en",
+	"helena":    "aura-2-helena-en",
+	"jason":     "aura-2-jason-en",
+	"apollo":    "aura-2-apollo-en",
+	"ares":      "aura-2-ares-en",
+}
+
+// Sample rates Deepgram accepts for each format. MP3 and Opus are
+// always encoded at their own fixed rate.
+var deepgramSampleRates = map[string][]int{
+	"wav":  {8000, 16000, 24000, 32000, 48000},
+	"pcm":  {8000, 16000, 24000, 32000, 48000},
+	"flac": {8000, 16000, 22050, 32000, 48000},
+}
+
+// DeepgramSampleRates returns the sample rates Deepgram can produce
+// format at, or nil if the rate is fixed.
+func DeepgramSampleRates(format string) []int {
+	return deepgramSampleRates[format]
+}
+
+// Deepgram TTS request
+type DeepgramTTSRequest struct {
+	Text string `json:"text"`
+}
+
+// Deepgram response to a request with a callback URL
+type DeepgramCallbackResponse struct {
+	RequestID string `json:"request_id"`
+}
+
+// Deepgram GET /v1/models response; only the TTS models are decoded
+type DeepgramModelsResponse struct {
+	TTS []struct {
+		Name          string `json:"name"`
+		CanonicalName string `json:"canonical_name"`
+		Metadata      struct {
+			Accent string   `json:"accent"`
+			Tags   []string `json:"tags"`
+		} `json:"metadata"`
+	} `json:"tts"`
+}
+
+// Full Deepgram voice model names: aura-asteria-en, aura-2-thalia-en
+var deepgramVoiceModel = regexp.MustCompile(`^aura(-2)?-[a-z]+-[a-z]{2}$`)
+
+// IsValidDeepgramVoice reports whether voice is a preset name from
+// DeepgramVoices or a full Aura model name such as aura-2-thalia-en.
+func IsValidDeepgramVoice(voice string) bool {
+	if _, ok := DeepgramVoices[strings.ToLower(voice)]; ok {
+		return true
+	}
+	return deepgramVoiceModel.MatchString(voice)
+}
+
+func resolveDeepgramVoice(voice string) string {
+	// Check if it's a preset name
+	if model, ok := DeepgramVoices[strings.ToLower(voice)]; ok {
+		return model
+	}
+	// Otherwise assume it's a full model name (e.g., aura-asteria-en)
+	return voice
+}
+
+// DeepgramProvider synthesizes speech with Deepgram Aura. The voice is
+// also the model, and Deepgram has no speed control, so Request.Model and
+// Request.Speed are ignored.
+type DeepgramProvider struct {
+	APIKey string
+	// SampleRate in Hz, one of DeepgramSampleRates for the format. Zero
+	// uses Deepgram's default.
+	SampleRate int
+	HTTPOptions
+}
+
+func (p DeepgramProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p DeepgramProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultDeepgramVoice, "", DefaultFormat("deepgram"))
+	reqBody := DeepgramTTSRequest{
+		Text: req.Text,
+	}
+	return p.post(ctx, p.speakURL(req), p.auth(), reqBody)
+}
+
+// SubmitAsync asks Deepgram to synthesize req and POST the audio to
+// callback when it is done, and returns the request ID without waiting.
+// Text longer than DeepgramMaxChars must be submitted in parts.
+func (p DeepgramProvider) SubmitAsync(ctx context.Context, req Request, callback string) (string, error) {
+	req = req.withDefaults(DefaultDeepgramVoice, "", DefaultFormat("deepgram"))
+	if callback == "" {
+		return "", errors.New("deepgram needs a callback URL to synthesize asynchronously")
+	}
+
+	body, err := p.post(ctx, p.speakURL(req)+"&callback="+url.QueryEscape(callback), p.auth(), DeepgramTTSRequest{Text: req.Text})
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	var resp DeepgramCallbackResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if resp.RequestID == "" {
+		return "", errors.New("deepgram returned no request ID")
+	}
+	return resp.RequestID, nil
+}
+
+// speakURL returns the /v1/speak URL for req's voice and format.
+func (p DeepgramProvider) speakURL(req Request) string {
+	endpoint := fmt.Sprintf("%s?model=%s&%s", deepgramAPIURL, resolveDeepgramVoice(req.Voice), formatToken("deepgram", req.Format))
+	if p.SampleRate > 0 {
+		endpoint += fmt.Sprintf("&sample_rate=%d", p.SampleRate)
+	}
+	return endpoint
+}
+
+// Voices lists the Aura voice models Deepgram offers.
+func (p DeepgramProvider) Voices(ctx context.Context) ([]Voice, error) {
+	var resp DeepgramModelsResponse
+	if err := p.get(ctx, deepgramModelsURL, p.auth(), &resp); err != nil {
+		return nil, err
+	}
+
+	voices := make([]Voice, len(resp.TTS))
+	for i, m := range resp.TTS {
+		desc := m.Metadata.Tags
+		if m.Metadata.Accent != "" {
+			desc = append([]string{m.Metadata.Accent}, desc...)
+		}
+		voices[i] = Voice{Name: m.Name, ID: m.CanonicalName, Description: strings.Join(desc, ", ")}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
+
+func (p DeepgramProvider) auth() authScheme {
+	return headerAuth{header: "Authorization", prefix: "Token ", key: p.APIKey}
+}
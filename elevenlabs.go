@@ -0,0 +1,497 @@
+package gospeak
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// ElevenLabs defaults
+	DefaultElevenLabsVoice      = "rachel"
+	DefaultElevenLabsModel      = "eleven_multilingual_v2"
+	DefaultElevenLabsStability  = 0.5
+	DefaultElevenLabsSimilarity = 0.75
+	ElevenLabsMaxChars          = 5000
+	elevenLabsAPIURL            = "https://api.elevenlabs.io/v1/text-to-speech"
+	elevenLabsVoicesURL         = "https://api.elevenlabs.io/v1/voices"
+	elevenLabsStreamURL         = "wss://api.elevenlabs.io/v1/text-to-speech"
+)
+
+// ElevenLabs voice presets (name -> voice_id)
+var ElevenLabsVoices = map[string]string{
+	"rachel":  "21m00Tcm4TlvDq8ikWAM",
+	"domi":    "AZnzlk1XvdvUeBnXmlld",
+	"bella":   "EXAVITQu4vr4xnSDxMaL",
+	"antoni":  "ErXwobaYiN019PkySvjV",
+	"elli":    "MF3mGyEYCl7XYWbV9V6O",
+	"josh":    "TxGEqnHWrfWFTfGW9XjX",
+	"arnold":  "VR6AewLTigWG4xSOukaG",
+	"adam":    "pNInz6obpgDQGcFmaJgB",
+	"sam":     "yoZ06aMxZJJ28mfd3POQ",
+	"george":  "JBFqnCBsd6RMkjVDRZzb",
+	"charlie": "IKne3meq5aSn9XLyUdCD",
+	"emily":   "LcfcDJNUP1GQjkzn1xUU",
+	"lily":    "pFZP5JQG7iQjIQuC4Bku",
+	"michael": "flq6f7yk4E4fJM5XTYuZ",
+}
+
+// ElevenLabsOutput is one of the output_format values ElevenLabs accepts:
+// a sample rate in Hz and, for compressed formats, a bitrate in kbps.
+type ElevenLabsOutput struct {
+	SampleRate int
+	Bitrate    int // zero for PCM
+}
+
+// ElevenLabsOutputs lists the outputs ElevenLabs can produce for each
+// format, lowest quality first. MP3 at 192 kbps needs a Creator plan or
+// above, and PCM at 44.1 kHz a Pro plan.
+var ElevenLabsOutputs = map[string][]ElevenLabsOutput{
+	"mp3": {
+		{22050, 32}, {24000, 48}, {44100, 32}, {44100, 64}, {44100, 96}, {44100, 128}, {44100, 192},
+	},
+	"opus": {
+		{48000, 32}, {48000, 64}, {48000, 96}, {48000, 128}, {48000, 192},
+	},
+	"pcm": {
+		{8000, 0}, {16000, 0}, {22050, 0}, {24000, 0}, {32000, 0}, {44100, 0}, {48000, 0},
+	},
+}
+
+// Outputs used when neither the sample rate nor the bitrate is given
+var elevenLabsDefaultOutputs = map[string]ElevenLabsOutput{
+	"mp3":  {44100, 128},
+	"opus": {48000, 128},
+	"pcm":  {24000, 0},
+}
+
+func (o ElevenLabsOutput) token(format string) string {
+	if o.Bitrate == 0 {
+		return fmt.Sprintf("%s_%d", format, o.SampleRate)
+	}
+	return fmt.Sprintf("%s_%d_%d", format, o.SampleRate, o.Bitrate)
+}
+
+// ElevenLabsOutputFormat returns the output_format to request format at
+// sampleRate Hz and bitrate kbps, such as mp3_44100_192. Zero leaves
+// either to the default for the format where it can, and to the highest
+// quality that fits the other otherwise. An error names the outputs
+// ElevenLabs has for the format if none matches.
+func ElevenLabsOutputFormat(format string, sampleRate, bitrate int) (string, error) {
+	outputs, ok := ElevenLabsOutputs[format]
+	if !ok {
+		return "", fmt.Errorf("Format '%s' is not supported by elevenlabs. Use %s", format, strings.Join(slices.Sorted(maps.Keys(ElevenLabsOutputs)), ", "))
+	}
+	var matches []ElevenLabsOutput
+	for _, o := range outputs {
+		if (sampleRate == 0 || o.SampleRate == sampleRate) && (bitrate == 0 || o.Bitrate == bitrate) {
+			matches = append(matches, o)
+		}
+	}
+	if len(matches) == 0 {
+		var asked []string
+		if sampleRate != 0 {
+			asked = append(asked, fmt.Sprintf("%d Hz", sampleRate))
+		}
+		if bitrate != 0 {
+			asked = append(asked, fmt.Sprintf("%d kbps", bitrate))
+		}
+		tokens := make([]string, len(outputs))
+		for i, o := range outputs {
+			tokens[i] = o.token(format)
+		}
+		return "", fmt.Errorf("ElevenLabs can't produce %s at %s. Use %s", format, strings.Join(asked, " and "), strings.Join(tokens, ", "))
+	}
+
+	def := elevenLabsDefaultOutputs[format]
+	for _, o := range matches {
+		if (sampleRate != 0 || o.SampleRate == def.SampleRate) && (bitrate != 0 || o.Bitrate == def.Bitrate) {
+			return o.token(format), nil
+		}
+	}
+	return matches[len(matches)-1].token(format), nil
+}
+
+// ElevenLabs TTS request
+type ElevenLabsTTSRequest struct {
+	Text          string                   `json:"text"`
+	ModelID       string                   `json:"model_id"`
+	VoiceSettings *ElevenLabsVoiceSettings `json:"voice_settings,omitempty"`
+	Seed          uint32                   `json:"seed,omitempty"`
+	LanguageCode  string                   `json:"language_code,omitempty"`
+}
+
+// ElevenLabs POST /v1/text-to-speech/{voice_id}/with-timestamps response
+type ElevenLabsTimestampsResponse struct {
+	AudioBase64 string     `json:"audio_base64"`
+	Alignment   *Alignment `json:"alignment"`
+}
+
+// Alignment gives the time, in seconds from the start of the clip, at
+// which each character of the text is spoken.
+type Alignment struct {
+	Characters []string  `json:"characters"`
+	StartTimes []float64 `json:"character_start_times_seconds"`
+	EndTimes   []float64 `json:"character_end_times_seconds"`
+}
+
+// ElevenLabs stream-input message, sent over the WebSocket
+type ElevenLabsStreamMessage struct {
+	Text          string                   `json:"text"`
+	VoiceSettings *ElevenLabsVoiceSettings `json:"voice_settings,omitempty"`
+}
+
+// ElevenLabs stream-input reply
+type ElevenLabsStreamResponse struct {
+	Audio   string `json:"audio"`
+	IsFinal bool   `json:"isFinal"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+// ElevenLabs GET /v1/voices response
+type ElevenLabsVoicesResponse struct {
+	Voices []struct {
+		VoiceID  string            `json:"voice_id"`
+		Name     string            `json:"name"`
+		Category string            `json:"category"`
+		Labels   map[string]string `json:"labels"`
+	} `json:"voices"`
+}
+
+type ElevenLabsVoiceSettings struct {
+	Stability       float64 `json:"stability"`
+	SimilarityBoost float64 `json:"similarity_boost"`
+	Style           float64 `json:"style,omitempty"`
+	UseSpeakerBoost *bool   `json:"use_speaker_boost,omitempty"`
+	Speed           float64 `json:"speed,omitempty"`
+}
+
+// ElevenLabs text-to-speech models
+var ElevenLabsModels = []string{
+	"eleven_multilingual_v2",
+	"eleven_v3",
+	"eleven_flash_v2_5",
+	"eleven_turbo_v2_5",
+	"eleven_flash_v2",
+	"eleven_turbo_v2",
+	"eleven_multilingual_v1",
+	"eleven_monolingual_v1",
+}
+
+// IsValidElevenLabsModel reports whether model is one of ElevenLabsModels.
+func IsValidElevenLabsModel(model string) bool {
+	for _, m := range ElevenLabsModels {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// Models that honour the style setting; the others ignore it
+var elevenLabsStyleModels = []string{"eleven_multilingual_v2", "eleven_v3"}
+
+// ElevenLabsSupportsStyle reports whether model applies the style setting.
+func ElevenLabsSupportsStyle(model string) bool {
+	for _, m := range elevenLabsStyleModels {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// ElevenLabsLanguages lists the ISO 639-1 codes ElevenLabs can be told
+// to speak in with language_code.
+var ElevenLabsLanguages = []string{
+	"ar", "bg", "cs", "da", "de", "el", "en", "es", "fi", "fil", "fr", "hi", "hr", "hu", "id", "it",
+	"ja", "ko", "ms", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sv", "ta", "tr", "uk", "vi", "zh",
+}
+
+// IsValidElevenLabsLanguage reports whether code is one of
+// ElevenLabsLanguages.
+func IsValidElevenLabsLanguage(code string) bool {
+	return slices.Contains(ElevenLabsLanguages, code)
+}
+
+// Models that take language_code; the others reject it
+var elevenLabsLanguageModels = []string{"eleven_v3", "eleven_flash_v2_5", "eleven_turbo_v2_5"}
+
+// ElevenLabsSupportsLanguage reports whether model can be told which
+// language to speak.
+func ElevenLabsSupportsLanguage(model string) bool {
+	return slices.Contains(elevenLabsLanguageModels, model)
+}
+
+// elevenLabsVoiceID matches a raw ElevenLabs voice_id.
+var elevenLabsVoiceID = regexp.MustCompile(`^[A-Za-z0-9]{20}$`)
+
+// Voices on each account (API key -> lower-case name -> voice_id),
+// fetched once per process on the first unknown voice name.
+var (
+	accountVoicesMu sync.Mutex
+	accountVoices   = map[string]map[string]string{}
+)
+
+// resolveVoice turns a preset name, the display name of a voice on the
+// account (such as a cloned voice), or a raw voice_id into a voice_id.
+func (p ElevenLabsProvider) resolveVoice(ctx context.Context, voice string) string {
+	// Check if it's a preset name
+	if id, ok := ElevenLabsVoices[strings.ToLower(voice)]; ok {
+		return id
+	}
+	if p.APIKey == "" || elevenLabsVoiceID.MatchString(voice) {
+		return voice
+	}
+	if id, ok := p.accountVoiceIDs(ctx)[strings.ToLower(voice)]; ok {
+		return id
+	}
+	// Otherwise assume it's a voice_id
+	return voice
+}
+
+// accountVoiceIDs returns the account's voices by lower-case name,
+// fetching them on first use. A failed fetch isn't cached, so the next
+// lookup tries again.
+func (p ElevenLabsProvider) accountVoiceIDs(ctx context.Context) map[string]string {
+	accountVoicesMu.Lock()
+	defer accountVoicesMu.Unlock()
+	if ids, ok := accountVoices[p.APIKey]; ok {
+		return ids
+	}
+	voices, err := p.Voices(ctx)
+	if err != nil {
+		return nil
+	}
+	ids := make(map[string]string, len(voices))
+	for _, v := range voices {
+		ids[strings.ToLower(v.Name)] = v.ID
+	}
+	accountVoices[p.APIKey] = ids
+	return ids
+}
+
+// ElevenLabsProvider synthesizes speech with the ElevenLabs API. Zero
+// voice settings are sent as zero; NewElevenLabsProvider starts from the
+// recommended values instead.
+type ElevenLabsProvider struct {
+	APIKey          string
+	Stability       float64
+	SimilarityBoost float64
+	Style           float64 // style exaggeration, 0.0-1.0
+	SpeakerBoost    *bool   // nil leaves the voice's own setting
+	// Language is an ISO 639-1 code, one of ElevenLabsLanguages, that
+	// the voice must speak in rather than the one it guesses from the
+	// text. It is only sent to models that take it.
+	Language string
+	// SampleRate in Hz and Bitrate in kbps pick the output_format, as
+	// listed in ElevenLabsOutputs. Zero means the format's default.
+	SampleRate int
+	Bitrate    int
+	HTTPOptions
+}
+
+func NewElevenLabsProvider(apiKey string) ElevenLabsProvider {
+	return ElevenLabsProvider{
+		APIKey:          apiKey,
+		Stability:       DefaultElevenLabsStability,
+		SimilarityBoost: DefaultElevenLabsSimilarity,
+	}
+}
+
+func (p ElevenLabsProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p ElevenLabsProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultElevenLabsVoice, DefaultElevenLabsModel, DefaultFormat("elevenlabs"))
+	outputFormat, err := ElevenLabsOutputFormat(req.Format, p.SampleRate, p.Bitrate)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/%s?output_format=%s", elevenLabsAPIURL, p.resolveVoice(ctx, req.Voice), outputFormat)
+	return p.post(ctx, url, headerAuth{header: "xi-api-key", key: p.APIKey}, p.ttsRequest(req))
+}
+
+// SynthesizeWithTimestamps synthesizes req like Synthesize, and also
+// returns when each character is spoken, for captions. ElevenLabs sends
+// the audio base64-encoded in a JSON document, so it can't stream.
+func (p ElevenLabsProvider) SynthesizeWithTimestamps(ctx context.Context, req Request) ([]byte, *Alignment, error) {
+	req = req.withDefaults(DefaultElevenLabsVoice, DefaultElevenLabsModel, DefaultFormat("elevenlabs"))
+	outputFormat, err := ElevenLabsOutputFormat(req.Format, p.SampleRate, p.Bitrate)
+	if err != nil {
+		return nil, nil, err
+	}
+	url := fmt.Sprintf("%s/%s/with-timestamps?output_format=%s", elevenLabsAPIURL, p.resolveVoice(ctx, req.Voice), outputFormat)
+	body, err := readAudio(p.post(ctx, url, headerAuth{header: "xi-api-key", key: p.APIKey}, p.ttsRequest(req)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp ElevenLabsTimestampsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	audioData, err := base64.StdEncoding.DecodeString(resp.AudioBase64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode audio content: %w", err)
+	}
+	if len(audioData) < minAudioBytes {
+		return nil, nil, shortAudioError(len(audioData))
+	}
+	if resp.Alignment == nil {
+		resp.Alignment = &Alignment{}
+	}
+	return audioData, resp.Alignment, nil
+}
+
+// StreamInput synthesizes req over ElevenLabs' stream-input WebSocket,
+// which starts sending audio sooner than Stream. The text is sent a
+// sentence at a time, so it isn't bound by ElevenLabsMaxChars, and the
+// audio is returned as it arrives. Closing the reader closes the socket.
+func (p ElevenLabsProvider) StreamInput(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultElevenLabsVoice, DefaultElevenLabsModel, DefaultFormat("elevenlabs"))
+	outputFormat, err := ElevenLabsOutputFormat(req.Format, p.SampleRate, p.Bitrate)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/%s/stream-input?model_id=%s&output_format=%s", elevenLabsStreamURL, p.resolveVoice(ctx, req.Voice), req.Model, outputFormat)
+	if lang := p.ttsRequest(req).LanguageCode; lang != "" {
+		url += "&language_code=" + lang
+	}
+	conn, err := p.dialWebSocket(ctx, url, headerAuth{header: "xi-api-key", key: p.APIKey})
+	if err != nil {
+		return nil, err
+	}
+
+	// The first message opens the stream with the voice settings, and an
+	// empty one ends it; text in between must end with a space
+	messages := []ElevenLabsStreamMessage{{Text: " ", VoiceSettings: p.ttsRequest(req).VoiceSettings}}
+	for _, sentence := range SplitSentences(req.Text) {
+		messages = append(messages, ElevenLabsStreamMessage{Text: sentence + " "})
+	}
+	messages = append(messages, ElevenLabsStreamMessage{})
+	for _, m := range messages {
+		data, err := json.Marshal(m)
+		if err == nil {
+			err = conn.writeMessage(data)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to send text: %w", err)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(p.receiveStream(conn, pw))
+	}()
+	// Stop the reader when ctx is cancelled, as for an HTTP body
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	return &elevenLabsStream{PipeReader: pr, conn: conn, stop: stop}, nil
+}
+
+// elevenLabsStream is the audio of a stream-input session.
+type elevenLabsStream struct {
+	*io.PipeReader
+	conn *wsConn
+	stop func() bool
+}
+
+func (s *elevenLabsStream) Close() error {
+	s.stop()
+	s.PipeReader.Close()
+	return s.conn.Close()
+}
+
+// receiveStream writes the audio of each stream-input reply to w until
+// the final one.
+func (p ElevenLabsProvider) receiveStream(conn *wsConn, w io.Writer) error {
+	for {
+		data, err := conn.readMessage()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var resp ElevenLabsStreamResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		if resp.Error != "" || (resp.Message != "" && resp.Audio == "") {
+			return errors.New(cmp.Or(resp.Message, resp.Error))
+		}
+		if resp.Audio != "" {
+			audio, err := base64.StdEncoding.DecodeString(resp.Audio)
+			if err != nil {
+				return fmt.Errorf("failed to decode audio content: %w", err)
+			}
+			if _, err := w.Write(audio); err != nil {
+				return err
+			}
+		}
+		if resp.IsFinal {
+			return nil
+		}
+	}
+}
+
+// ttsRequest builds the request body shared by both speech endpoints.
+func (p ElevenLabsProvider) ttsRequest(req Request) ElevenLabsTTSRequest {
+	body := ElevenLabsTTSRequest{
+		Text:    req.Text,
+		ModelID: req.Model,
+		Seed:    req.Seed,
+		VoiceSettings: &ElevenLabsVoiceSettings{
+			Stability:       p.Stability,
+			SimilarityBoost: p.SimilarityBoost,
+			Style:           p.Style,
+			UseSpeakerBoost: p.SpeakerBoost,
+			Speed:           req.Speed,
+		},
+	}
+	if ElevenLabsSupportsLanguage(req.Model) {
+		body.LanguageCode = p.Language
+	}
+	return body
+}
+
+// Voices lists the premade and custom voices on the account.
+func (p ElevenLabsProvider) Voices(ctx context.Context) ([]Voice, error) {
+	var resp ElevenLabsVoicesResponse
+	if err := p.get(ctx, elevenLabsVoicesURL, headerAuth{header: "xi-api-key", key: p.APIKey}, &resp); err != nil {
+		return nil, err
+	}
+
+	voices := make([]Voice, len(resp.Voices))
+	for i, v := range resp.Voices {
+		// Labels such as accent, gender, and age, in a stable order
+		keys := make([]string, 0, len(v.Labels))
+		for k := range v.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var desc []string
+		if v.Category != "" {
+			desc = append(desc, v.Category)
+		}
+		for _, k := range keys {
+			desc = append(desc, v.Labels[k])
+		}
+		voices[i] = Voice{Name: v.Name, ID: v.VoiceID, Description: strings.Join(desc, ", ")}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
@@ -0,0 +1,87 @@
+package gospeak
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultEspeakBin is the espeak-ng executable looked up on the PATH.
+	DefaultEspeakBin = "espeak-ng"
+	// DefaultEspeakVoice is the espeak-ng voice used when none is given.
+	DefaultEspeakVoice = "en"
+	// DefaultEspeakRate is the rate espeak-ng speaks at, in words per
+	// minute, when none is given.
+	DefaultEspeakRate = 175
+)
+
+// EspeakProvider synthesizes speech offline with espeak-ng. The voice is
+// robotic, but espeak-ng is packaged for nearly every Linux distribution
+// and needs no audio API, so it suits CI and containers. Request.Voice is
+// an espeak-ng voice such as en or de; Request.Model is ignored.
+type EspeakProvider struct {
+	Bin string // defaults to espeak-ng on the PATH
+}
+
+// Command returns the espeak-ng invocation Synthesize runs for req,
+// without starting it. Its stdin is set to the text; stdout and stderr
+// are left for the caller.
+func (p EspeakProvider) Command(ctx context.Context, req Request) (*exec.Cmd, error) {
+	req = req.withDefaults(DefaultEspeakVoice, "", DefaultFormat("espeak"))
+	bin := p.Bin
+	if bin == "" {
+		bin = DefaultEspeakBin
+	}
+
+	args := []string{"--stdout", "--stdin", "-v", req.Voice, "-s", strconv.Itoa(int(DefaultEspeakRate*req.Speed + 0.5))}
+	if req.SSML {
+		args = append(args, "-m")
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = strings.NewReader(req.Text)
+	return cmd, nil
+}
+
+// Synthesize runs espeak-ng, feeding the text on stdin and capturing the
+// WAV it writes to stdout. Speed scales espeak-ng's default rate of 175
+// words per minute.
+func (p EspeakProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	cmd, err := p.Command(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("espeak-ng failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("espeak-ng failed: %w", err)
+	}
+	audioData := stdout.Bytes()
+	if !isWAV(audioData) {
+		return nil, errors.New("espeak-ng did not produce WAV audio")
+	}
+	fixStreamedWAVSizes(audioData)
+	return audioData, nil
+}
+
+// fixStreamedWAVSizes fills in the RIFF and data sizes of a WAV written
+// to a pipe, which can't go back to set them and leaves placeholders, so
+// the length of the audio can be read from its header. Only the plain
+// 44-byte header is handled; other layouts are left as they are.
+func fixStreamedWAVSizes(wav []byte) {
+	if len(wav) < 44 || string(wav[36:40]) != "data" {
+		return
+	}
+	binary.LittleEndian.PutUint32(wav[4:], uint32(len(wav)-8))
+	binary.LittleEndian.PutUint32(wav[40:], uint32(len(wav)-44))
+}
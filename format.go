@@ -0,0 +1,165 @@
+package gospeak
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AudioFormats lists the output formats gospeak knows about.
+var AudioFormats = []string{"mp3", "wav", "opus", "flac", "pcm"}
+
+// providerFormat maps one of AudioFormats to the provider's own encoding
+// token, as sent in its request.
+type providerFormat struct {
+	name  string
+	token string
+}
+
+// Formats each provider can return, default first
+var providerFormats = map[string][]providerFormat{
+	"openai": {
+		{"mp3", "mp3"}, {"wav", "wav"}, {"opus", "opus"}, {"flac", "flac"}, {"pcm", "pcm"},
+	},
+	"elevenlabs": {
+		{"mp3", "mp3_44100_128"}, {"opus", "opus_48000_128"}, {"pcm", "pcm_24000"},
+	},
+	"deepgram": {
+		{"mp3", "encoding=mp3"}, {"wav", "encoding=linear16&container=wav"}, {"opus", "encoding=opus"},
+		{"flac", "encoding=flac"}, {"pcm", "encoding=linear16&container=none"},
+	},
+	"google": {
+		{"mp3", "MP3"}, {"wav", "LINEAR16"}, {"opus", "OGG_OPUS"},
+	},
+	"polly": {
+		{"mp3", "mp3"}, {"pcm", "pcm"},
+	},
+	"azure": {
+		{"mp3", "audio-24khz-48kbitrate-mono-mp3"}, {"wav", "riff-24khz-16bit-mono-pcm"},
+		{"opus", "ogg-24khz-16bit-mono-opus"}, {"pcm", "raw-24khz-16bit-mono-pcm"},
+	},
+	"playht": {
+		{"mp3", "mp3"}, {"wav", "wav"}, {"flac", "flac"}, {"pcm", "raw"},
+	},
+	"watson": {
+		{"mp3", "audio/mp3"}, {"wav", "audio/wav"}, {"opus", "audio/ogg;codecs=opus"},
+		{"flac", "audio/flac"}, {"pcm", "audio/l16;rate=24000;endianness=little-endian"},
+	},
+	"lmnt": {
+		{"mp3", "mp3"}, {"wav", "wav"}, {"pcm", "raw"},
+	},
+	"resemble": {
+		{"mp3", "mp3"}, {"wav", "wav"},
+	},
+	"piper": {
+		{"wav", "wav"}, {"pcm", "pcm"},
+	},
+	"say": {
+		{"wav", "wav"},
+	},
+	"espeak": {
+		{"wav", "wav"},
+	},
+}
+
+// DefaultFormat returns the format provider returns when none is asked for.
+func DefaultFormat(provider string) string {
+	if formats := providerFormats[provider]; len(formats) > 0 {
+		return formats[0].name
+	}
+	return "mp3"
+}
+
+// formatToken returns the provider's encoding token for format, or ""
+// if the provider can't produce it.
+func formatToken(provider, format string) string {
+	for _, f := range providerFormats[provider] {
+		if f.name == format {
+			return f.token
+		}
+	}
+	return ""
+}
+
+// Formats returns the formats provider can return, default first.
+func Formats(provider string) []string {
+	names := make([]string, len(providerFormats[provider]))
+	for i, f := range providerFormats[provider] {
+		names[i] = f.name
+	}
+	return names
+}
+
+// ValidateFormat checks that the provider can return audio in format.
+func ValidateFormat(provider, format string) error {
+	if !IsValidFormat(format) {
+		return fmt.Errorf("Invalid format '%s'. Use %s", format, strings.Join(AudioFormats, ", "))
+	}
+	if formatToken(provider, format) == "" {
+		return fmt.Errorf("Format '%s' is not supported by %s. Use %s", format, provider, strings.Join(Formats(provider), ", "))
+	}
+	return nil
+}
+
+func IsValidFormat(format string) bool {
+	for _, f := range AudioFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPlayableFormat reports whether Play can decode format. The other
+// formats can only be saved.
+func IsPlayableFormat(format string) bool {
+	return format == "mp3" || format == "wav" || format == "opus"
+}
+
+// JoinAudio combines separately synthesized clips of the same format into
+// one. An empty format means MP3, WAV, or Opus, whichever the clips are.
+// Raw PCM can simply be appended, MP3 frames are joined without their
+// per-file headers, Opus packets are copied into one Ogg stream, and WAV
+// clips are decoded and rewritten under a single header.
+func JoinAudio(chunks [][]byte, format string) ([]byte, error) {
+	if len(chunks) == 1 {
+		return chunks[0], nil
+	}
+	// Without a format, tell the providers' default containers apart
+	if format == "" {
+		format = "mp3"
+		if isWAV(chunks[0]) {
+			format = "wav"
+		} else if isOggOpus(chunks[0]) {
+			format = "opus"
+		}
+	}
+	switch format {
+	case "mp3":
+		return concatMP3(chunks)
+	case "opus":
+		return concatOpus(chunks)
+	case "pcm":
+		return bytes.Join(chunks, nil), nil
+	case "wav":
+		var pcm []byte
+		rate := 0
+		for _, chunk := range chunks {
+			samples, sampleRate, err := DecodeAudio(bytes.NewReader(chunk))
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(samples)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode WAV: %w", err)
+			}
+			if rate == 0 {
+				rate = sampleRate
+			}
+			pcm = append(pcm, resamplePCM(data, sampleRate, rate)...)
+		}
+		return encodeWAV(pcm, rate), nil
+	}
+	return nil, fmt.Errorf("text too long to synthesize as a single %s clip; use mp3, wav, opus, or pcm", format)
+}
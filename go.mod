@@ -1,10 +1,12 @@
-module gospeak
+module github.com/schappim/gospeak
 
 go 1.25.5
 
 require (
 	github.com/ebitengine/oto/v3 v3.4.0
 	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/thesyncim/gopus v0.1.2
+	golang.org/x/term v0.35.0
 )
 
 require (
@@ -0,0 +1,127 @@
+package gospeak
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Google Cloud Text-to-Speech defaults
+	DefaultGoogleVoice = "en-US-Wavenet-D"
+	GoogleMaxChars     = 5000
+	googleAPIURL       = "https://texttospeech.googleapis.com/v1/text:synthesize"
+)
+
+// Google voice presets (short name -> voice name)
+var GoogleVoices = map[string]string{
+	"wavenet-a":    "en-US-Wavenet-A",
+	"wavenet-b":    "en-US-Wavenet-B",
+	"wavenet-c":    "en-US-Wavenet-C",
+	"wavenet-d":    "en-US-Wavenet-D",
+	"wavenet-f":    "en-US-Wavenet-F",
+	"neural2-a":    "en-US-Neural2-A",
+	"neural2-c":    "en-US-Neural2-C",
+	"neural2-d":    "en-US-Neural2-D",
+	"neural2-f":    "en-US-Neural2-F",
+	"studio-o":     "en-US-Studio-O",
+	"studio-q":     "en-US-Studio-Q",
+	"uk-wavenet-a": "en-GB-Wavenet-A",
+	"uk-wavenet-b": "en-GB-Wavenet-B",
+	"uk-neural2-a": "en-GB-Neural2-A",
+}
+
+// Google TTS request
+type GoogleTTSRequest struct {
+	Input       GoogleTTSInput       `json:"input"`
+	Voice       GoogleTTSVoice       `json:"voice"`
+	AudioConfig GoogleTTSAudioConfig `json:"audioConfig"`
+}
+
+type GoogleTTSInput struct {
+	Text string `json:"text,omitempty"`
+	SSML string `json:"ssml,omitempty"`
+}
+
+type GoogleTTSVoice struct {
+	LanguageCode string `json:"languageCode"`
+	Name         string `json:"name"`
+}
+
+type GoogleTTSAudioConfig struct {
+	AudioEncoding string  `json:"audioEncoding"`
+	SpeakingRate  float64 `json:"speakingRate"`
+}
+
+// Google TTS response
+type GoogleTTSResponse struct {
+	AudioContent string `json:"audioContent"`
+}
+
+func resolveGoogleVoice(voice string) string {
+	// Check if it's a preset name
+	if name, ok := GoogleVoices[strings.ToLower(voice)]; ok {
+		return name
+	}
+	// Otherwise assume it's a full voice name (e.g., en-US-Wavenet-D)
+	return voice
+}
+
+// googleLanguageCode derives the language code from a voice name:
+// "en-US-Wavenet-D" -> "en-US".
+func googleLanguageCode(voiceName string) string {
+	parts := strings.SplitN(voiceName, "-", 3)
+	if len(parts) < 2 {
+		return "en-US"
+	}
+	return parts[0] + "-" + parts[1]
+}
+
+// GoogleProvider synthesizes speech with Google Cloud Text-to-Speech. The
+// voice name implies the model, so Request.Model is ignored. Google
+// returns the whole clip inside a JSON document, so it can't stream.
+type GoogleProvider struct {
+	APIKey string
+	HTTPOptions
+}
+
+func (p GoogleProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	req = req.withDefaults(DefaultGoogleVoice, "", DefaultFormat("google"))
+	voice := resolveGoogleVoice(req.Voice)
+	input := GoogleTTSInput{Text: req.Text}
+	if req.SSML {
+		input = GoogleTTSInput{SSML: wrapSSML(req.Text)}
+	}
+	reqBody := GoogleTTSRequest{
+		Input: input,
+		Voice: GoogleTTSVoice{
+			LanguageCode: googleLanguageCode(voice),
+			Name:         voice,
+		},
+		AudioConfig: GoogleTTSAudioConfig{
+			AudioEncoding: formatToken("google", req.Format),
+			SpeakingRate:  req.Speed,
+		},
+	}
+
+	body, err := readAudio(p.post(ctx, googleAPIURL, queryAuth{param: "key", key: p.APIKey}, reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// The audio comes back base64-encoded inside a JSON document
+	var resp GoogleTTSResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	audioData, err := base64.StdEncoding.DecodeString(resp.AudioContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio content: %w", err)
+	}
+	if len(audioData) < minAudioBytes {
+		return nil, shortAudioError(len(audioData))
+	}
+	return audioData, nil
+}
@@ -0,0 +1,87 @@
+// Package gospeak synthesizes speech with OpenAI, ElevenLabs, Deepgram,
+// Google Cloud, AWS Polly, Azure, Play.ht, IBM Watson, LMNT, Resemble AI,
+// a local Piper install, the macOS say command, or espeak-ng, and plays
+// it without any external tools.
+//
+//	p := gospeak.OpenAIProvider{APIKey: os.Getenv("OPENAI_API_KEY")}
+//	audio, err := p.Synthesize(ctx, gospeak.Request{Text: "Hello", Voice: "nova"})
+//	if err == nil {
+//		err = gospeak.Play(ctx, audio)
+//	}
+package gospeak
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// DefaultSpeed is normal speaking speed.
+const DefaultSpeed = 1.0
+
+// Request describes one clip to synthesize. Empty fields take the
+// provider's defaults.
+type Request struct {
+	Text   string
+	Voice  string  // preset name or the provider's own voice ID
+	Model  string  // model; the engine for Polly, the .onnx path for Piper
+	Format string  // one of AudioFormats
+	Speed  float64 // 1.0 is normal speed
+	SSML   bool    // Text is SSML markup
+	Seed   uint32  // for reproducible output where supported; 0 means none
+}
+
+// withDefaults fills in the empty fields of r.
+func (r Request) withDefaults(voice, model, format string) Request {
+	if r.Voice == "" {
+		r.Voice = voice
+	}
+	if r.Model == "" {
+		r.Model = model
+	}
+	if r.Format == "" {
+		r.Format = format
+	}
+	if r.Speed == 0 {
+		r.Speed = DefaultSpeed
+	}
+	return r
+}
+
+// Provider synthesizes speech.
+type Provider interface {
+	Synthesize(ctx context.Context, req Request) ([]byte, error)
+}
+
+// StreamProvider is a Provider that can also return audio while it is
+// still downloading. The caller must close the returned reader.
+type StreamProvider interface {
+	Provider
+	Stream(ctx context.Context, req Request) (io.ReadCloser, error)
+}
+
+// SynthesizeLong synthesizes text of any length with p. Text over
+// maxChars characters is split between sentences, each piece synthesized
+// separately, and the clips joined into one. SSML is never split, since a
+// cut could land inside a tag. A maxChars of zero or less means no limit.
+func SynthesizeLong(ctx context.Context, p Provider, req Request, maxChars int) ([]byte, error) {
+	chunks := []string{req.Text}
+	if !req.SSML {
+		chunks = SplitText(req.Text, maxChars)
+	}
+	if len(chunks) == 1 {
+		return p.Synthesize(ctx, req)
+	}
+
+	clips := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		part := req
+		part.Text = chunk
+		audioData, err := p.Synthesize(ctx, part)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		clips[i] = audioData
+	}
+	return JoinAudio(clips, req.Format)
+}
@@ -0,0 +1,262 @@
+package gospeak
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTimeout      = 60 * time.Second
+	defaultMaxRetryWait = 30 * time.Second
+	retryBaseDelay      = 500 * time.Millisecond
+	// maxBackoffDoublings keeps the backoff far from overflowing; 500ms
+	// doubled 30 times is already years.
+	maxBackoffDoublings = 30
+	// minAudioBytes is the smallest body that can hold any real clip; a
+	// WAV header alone is 44 bytes.
+	minAudioBytes = 44
+)
+
+// ErrShortAudio means a provider answered 200 OK with an empty or
+// truncated body, as some do when the account is out of quota.
+var ErrShortAudio = errors.New("API returned an empty or truncated audio body")
+
+func shortAudioError(n int) error {
+	return fmt.Errorf("%w (%d bytes with status 200)", ErrShortAudio, n)
+}
+
+var defaultClient = NewHTTPClient(nil)
+
+// NewHTTPClient returns a client with a 60 second timeout that sends
+// requests through proxy, which may be an http, https, or socks5 URL.
+// A nil proxy means the one named by HTTPS_PROXY, HTTP_PROXY, and
+// NO_PROXY, if any.
+func NewHTTPClient(proxy *url.URL) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &http.Client{Timeout: defaultTimeout, Transport: transport}
+}
+
+// HTTPOptions configures how a cloud provider talks to its API. The zero
+// value uses a client with a 60 second timeout and makes no retries.
+type HTTPOptions struct {
+	// Client sends the requests. Nil means a client with a 60s timeout
+	// that honours the proxy environment variables. The client's timeout
+	// applies to each attempt on its own, so retries get a fresh one.
+	Client *http.Client
+	// MaxRetries is how often to retry on rate limiting, transient server
+	// errors, and network errors.
+	MaxRetries int
+	// MaxRetryWait caps any single wait between retries. Zero means 30s.
+	MaxRetryWait time.Duration
+	// OnRetry, if set, is called before each retry with the failure and
+	// the wait that follows.
+	OnRetry func(err error, delay time.Duration)
+	// Limiter, if set, paces every attempt, retries included, so requests
+	// from several goroutines sharing it stay within its rate.
+	Limiter *RateLimiter
+}
+
+func (o HTTPOptions) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return defaultClient
+}
+
+// post sends reqBody as JSON to url, authenticated with auth, and returns
+// the body of a successful response for the caller to read and close.
+func (o HTTPOptions) post(ctx context.Context, url string, auth authScheme, reqBody any) (io.ReadCloser, error) {
+	return o.postWithHeader(ctx, url, auth, http.Header{}, reqBody)
+}
+
+// postWithHeader is post with extra request headers.
+func (o HTTPOptions) postWithHeader(ctx context.Context, url string, auth authScheme, header http.Header, reqBody any) (io.ReadCloser, error) {
+	// Encode without HTML escaping so SSML tags reach the provider intact
+	var jsonData bytes.Buffer
+	enc := json.NewEncoder(&jsonData)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(reqBody); err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	header = header.Clone()
+	header.Set("Content-Type", "application/json")
+	return o.send(ctx, "POST", url, auth, header, &jsonData)
+}
+
+// get fetches url, authenticated with auth, and decodes the JSON response
+// into v.
+func (o HTTPOptions) get(ctx context.Context, url string, auth authScheme, v any) error {
+	body, err := o.send(ctx, "GET", url, auth, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// send makes the request with the given extra headers, retrying as
+// configured, and returns the body of a successful response.
+func (o HTTPOptions) send(ctx context.Context, method, url string, auth authScheme, header http.Header, body io.Reader) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, values := range header {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	auth.applyAuth(req)
+
+	resp, err := o.doRequestWithRetry(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: apiErrorMessage(body), Body: body}
+	}
+
+	return resp.Body, nil
+}
+
+// readAudio reads and closes a response body opened by one of the
+// Stream methods. A body too small to be audio is an ErrShortAudio.
+func readAudio(body io.ReadCloser, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < minAudioBytes {
+		return nil, shortAudioError(len(data))
+	}
+	return data, nil
+}
+
+// PeekAudio waits until a body returned by Stream has delivered enough
+// bytes to be audio, and reports ErrShortAudio if it ends first. The
+// returned reader still yields the whole body.
+func PeekAudio(body io.ReadCloser) (io.ReadCloser, error) {
+	buffered := bufio.NewReaderSize(body, minAudioBytes)
+	if peeked, err := buffered.Peek(minAudioBytes); err != nil {
+		body.Close()
+		if err == io.EOF {
+			return nil, shortAudioError(len(peeked))
+		}
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{buffered, body}, nil
+}
+
+// isRetryableStatus reports whether a response status is worth retrying:
+// rate limiting or a transient server error.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retry number attempt
+// (starting at 0): the server's Retry-After if it sent one, otherwise
+// exponential backoff with +/-50% jitter. Either way it is capped at
+// maxWait.
+func retryDelay(resp *http.Response, attempt int, maxWait time.Duration) time.Duration {
+	var d time.Duration
+	if resp != nil {
+		if after := resp.Header.Get("Retry-After"); after != "" {
+			if secs, err := strconv.Atoi(after); err == nil {
+				d = time.Duration(secs) * time.Second
+			} else if t, err := http.ParseTime(after); err == nil {
+				d = time.Until(t)
+			}
+		}
+	}
+	if d <= 0 {
+		backoff := retryBaseDelay << min(attempt, maxBackoffDoublings)
+		if maxWait > 0 {
+			backoff = min(backoff, maxWait)
+		}
+		d = time.Duration(rand.Int64N(int64(backoff))) + backoff/2
+	}
+	return min(d, maxWait)
+}
+
+// doRequestWithRetry sends req, retrying on network errors and retryable
+// statuses up to o.MaxRetries times. The body is buffered up front and
+// rewound before every attempt.
+func (o HTTPOptions) doRequestWithRetry(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+	}
+	maxWait := o.MaxRetryWait
+	if maxWait <= 0 {
+		maxWait = defaultMaxRetryWait
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := o.Limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := o.client().Do(req)
+		retryable := err != nil || isRetryableStatus(resp.StatusCode)
+		if !retryable || attempt >= o.MaxRetries || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay := retryDelay(resp, attempt, maxWait)
+		cause := err
+		if err == nil {
+			cause = fmt.Errorf("API returned %d", resp.StatusCode)
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if o.OnRetry != nil {
+			o.OnRetry(cause, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
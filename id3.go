@@ -0,0 +1,87 @@
+package gospeak
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// MP3Tags is the metadata TagMP3 writes. Empty fields are left out.
+type MP3Tags struct {
+	Title   string
+	Artist  string
+	Comment string
+}
+
+// TagMP3 returns the MP3 audioData with an ID3v2.3 tag holding tags, in
+// place of any ID3v2 tag it had, so music players show more than
+// "Unknown". ID3v2.3 is the version players most widely read.
+func TagMP3(audioData []byte, tags MP3Tags) ([]byte, error) {
+	if _, _, _, err := mp3Frames(audioData); err != nil {
+		return nil, err
+	}
+
+	var frames []byte
+	if tags.Title != "" {
+		frames = append(frames, id3Frame("TIT2", id3Text(tags.Title, false))...)
+	}
+	if tags.Artist != "" {
+		frames = append(frames, id3Frame("TPE1", id3Text(tags.Artist, false))...)
+	}
+	if tags.Comment != "" {
+		// Encoding, language, and an empty description before the text
+		text := id3Text(tags.Comment, true)
+		comm := append([]byte{text[0]}, "eng"...)
+		frames = append(frames, id3Frame("COMM", append(comm, text[1:]...))...)
+	}
+
+	audio := audioData[id3v2Size(audioData):]
+	if len(frames) == 0 {
+		return audio, nil
+	}
+	// The tag size is syncsafe: 7 bits in each byte
+	n := len(frames)
+	out := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	out = append(out, frames...)
+	return append(out, audio...), nil
+}
+
+// id3Frame returns an ID3v2.3 frame with the given ID and body.
+func id3Frame(id string, body []byte) []byte {
+	frame := append([]byte(id), 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(frame[4:], uint32(len(body)))
+	return append(frame, body...)
+}
+
+// id3Text encodes s as the body of a text frame: an encoding byte, then
+// ISO-8859-1 when s fits in it, or UTF-16 with a byte order mark. With
+// description, an empty, terminated description comes before s, as a
+// COMM frame has.
+func id3Text(s string, description bool) []byte {
+	latin1 := true
+	for _, r := range s {
+		if r > 0xFF {
+			latin1 = false
+			break
+		}
+	}
+
+	if latin1 {
+		out := []byte{0}
+		if description {
+			out = append(out, 0)
+		}
+		for _, r := range s {
+			out = append(out, byte(r))
+		}
+		return out
+	}
+	out := []byte{1}
+	if description {
+		out = append(out, 0xFF, 0xFE, 0, 0)
+	}
+	out = append(out, 0xFF, 0xFE)
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package gospeak
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	// LMNT defaults
+	DefaultLMNTVoice      = "lily"
+	DefaultLMNTSampleRate = 24000
+	LMNTMaxChars          = 5000
+	lmntAPIURL            = "https://api.lmnt.com/v1/ai/speech/bytes"
+	lmntVoicesURL         = "https://api.lmnt.com/v1/ai/voice/list"
+)
+
+// LMNTSampleRates lists the sample rates LMNT can produce, in Hz.
+var LMNTSampleRates = []int{8000, 16000, 24000}
+
+// LMNT voice presets (name -> voice ID)
+var LMNTVoices = map[string]string{
+	"amy":     "amy",
+	"ansel":   "ansel",
+	"ava":     "ava",
+	"caleb":   "caleb",
+	"chloe":   "chloe",
+	"daniel":  "daniel",
+	"juniper": "juniper",
+	"lily":    "lily",
+	"morgan":  "morgan",
+	"nathan":  "nathan",
+	"sophie":  "sophie",
+	"tyler":   "tyler",
+	"zeke":    "zeke",
+}
+
+// LMNT TTS request
+type LMNTTTSRequest struct {
+	Voice      string  `json:"voice"`
+	Text       string  `json:"text"`
+	Format     string  `json:"format"`
+	SampleRate int     `json:"sample_rate"`
+	Speed      float64 `json:"speed,omitempty"`
+}
+
+// LMNT GET /v1/ai/voice/list response
+type LMNTVoicesResponse []struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Gender      string `json:"gender"`
+	Description string `json:"description"`
+}
+
+func resolveLMNTVoice(voice string) string {
+	// Check if it's a preset name
+	if id, ok := LMNTVoices[strings.ToLower(voice)]; ok {
+		return id
+	}
+	// Otherwise assume it's a voice ID, e.g. of a cloned voice
+	return voice
+}
+
+// LMNTProvider synthesizes speech with LMNT, whose voices are built for
+// low latency. The voice implies the model, so Request.Model is ignored.
+type LMNTProvider struct {
+	APIKey string
+	// SampleRate in Hz, one of LMNTSampleRates. Zero means 24000.
+	SampleRate int
+	HTTPOptions
+}
+
+func (p LMNTProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p LMNTProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultLMNTVoice, "", DefaultFormat("lmnt"))
+	rate := p.SampleRate
+	if rate == 0 {
+		rate = DefaultLMNTSampleRate
+	}
+	if !slices.Contains(LMNTSampleRates, rate) {
+		return nil, fmt.Errorf("lmnt can't produce audio at %d Hz", rate)
+	}
+
+	reqBody := LMNTTTSRequest{
+		Voice:      resolveLMNTVoice(req.Voice),
+		Text:       req.Text,
+		Format:     formatToken("lmnt", req.Format),
+		SampleRate: rate,
+	}
+	if req.Speed != DefaultSpeed {
+		reqBody.Speed = req.Speed
+	}
+	return p.post(ctx, lmntAPIURL, p.auth(), reqBody)
+}
+
+// Voices lists the LMNT voices the account can use, its own clones
+// included.
+func (p LMNTProvider) Voices(ctx context.Context) ([]Voice, error) {
+	var resp LMNTVoicesResponse
+	if err := p.get(ctx, lmntVoicesURL, p.auth(), &resp); err != nil {
+		return nil, err
+	}
+
+	voices := make([]Voice, len(resp))
+	for i, v := range resp {
+		var desc []string
+		for _, d := range []string{v.Gender, v.Description} {
+			if d != "" {
+				desc = append(desc, d)
+			}
+		}
+		if v.Owner != "" && v.Owner != "system" {
+			desc = append(desc, "owned by "+v.Owner)
+		}
+		voices[i] = Voice{Name: v.Name, ID: v.ID, Description: strings.Join(desc, ", ")}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
+
+func (p LMNTProvider) auth() authScheme {
+	return headerAuth{header: "X-API-Key", key: p.APIKey}
+}
@@ -0,0 +1,147 @@
+package gospeak
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// MPEG audio bitrates in kbit/s for Layer III, by version, and sample
+// rates in Hz, indexed by the header fields.
+var (
+	mp3BitratesV1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3BitratesV2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3Rates      = map[byte][3]int{
+		3: {44100, 48000, 32000}, // MPEG 1
+		2: {22050, 24000, 16000}, // MPEG 2
+		0: {11025, 12000, 8000},  // MPEG 2.5
+	}
+)
+
+// mp3Frame describes one MPEG Layer III frame header.
+type mp3Frame struct {
+	version    byte // 3 = MPEG 1, 2 = MPEG 2, 0 = MPEG 2.5
+	sampleRate int
+	mono       bool
+	length     int
+}
+
+// parseMP3Frame parses the frame header at the start of data.
+func parseMP3Frame(data []byte) (mp3Frame, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := (data[1] >> 3) & 0x03
+	layer := (data[1] >> 1) & 0x03
+	bitrateIdx := data[2] >> 4
+	rateIdx := (data[2] >> 2) & 0x03
+	padding := int((data[2] >> 1) & 0x01)
+	rates, ok := mp3Rates[version]
+	if !ok || layer != 1 || rateIdx == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{version: version, sampleRate: rates[rateIdx], mono: data[3]>>6 == 3}
+	if version == 3 {
+		f.length = 144*mp3BitratesV1[bitrateIdx]*1000/f.sampleRate + padding
+	} else {
+		f.length = 72*mp3BitratesV2[bitrateIdx]*1000/f.sampleRate + padding
+	}
+	if f.length <= 4 {
+		return mp3Frame{}, false
+	}
+	return f, true
+}
+
+// isInfoFrame reports whether frame is a Xing/Info or VBRI header: a
+// silent frame whose length fields describe the original file, and would
+// make players stop early once files are joined.
+func isInfoFrame(frame []byte, f mp3Frame) bool {
+	// The Xing tag follows the side information, whose size depends on
+	// the version and channel count
+	offset := 4 + 32
+	switch {
+	case f.version == 3 && f.mono, f.version != 3 && !f.mono:
+		offset = 4 + 17
+	case f.version != 3 && f.mono:
+		offset = 4 + 9
+	}
+	if len(frame) >= offset+4 {
+		if tag := string(frame[offset : offset+4]); tag == "Xing" || tag == "Info" {
+			return true
+		}
+	}
+	return len(frame) >= 40 && string(frame[36:40]) == "VBRI"
+}
+
+// id3v2Size returns the length of the ID3v2 tag at the start of data,
+// or zero if there is none.
+func id3v2Size(data []byte) int {
+	if len(data) < 10 || string(data[0:3]) != "ID3" {
+		return 0
+	}
+	size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+	size += 10
+	if data[5]&0x10 != 0 {
+		size += 10 // footer
+	}
+	return min(size, len(data))
+}
+
+// mp3Frames strips the tags from an MP3 file and returns its audio
+// frames, along with the ID3v2 tag and the sample rate.
+func mp3Frames(data []byte) (frames, tag []byte, sampleRate int, err error) {
+	n := id3v2Size(data)
+	tag, data = data[:n], data[n:]
+
+	// An ID3v1 tag is the last 128 bytes
+	if len(data) >= 128 && string(data[len(data)-128:len(data)-125]) == "TAG" {
+		data = data[:len(data)-128]
+	}
+
+	// Skip anything before the first valid frame
+	start := bytes.IndexByte(data, 0xFF)
+	for start >= 0 {
+		if _, ok := parseMP3Frame(data[start:]); ok {
+			break
+		}
+		next := bytes.IndexByte(data[start+1:], 0xFF)
+		if next < 0 {
+			start = -1
+			break
+		}
+		start += 1 + next
+	}
+	if start < 0 {
+		return nil, nil, 0, errors.New("no MP3 frames found")
+	}
+	data = data[start:]
+
+	f, _ := parseMP3Frame(data)
+	if f.length <= len(data) && isInfoFrame(data[:f.length], f) {
+		data = data[f.length:]
+	}
+	return data, tag, f.sampleRate, nil
+}
+
+// concatMP3 joins MP3 clips into one file. Only the first clip keeps its
+// ID3v2 tag, and every Xing/Info header frame is dropped, so the result
+// is a single continuous stream of frames that plays without gaps.
+func concatMP3(chunks [][]byte) ([]byte, error) {
+	var out []byte
+	rate := 0
+	for i, chunk := range chunks {
+		frames, tag, sampleRate, err := mp3Frames(chunk)
+		if err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i+1, err)
+		}
+		if i == 0 {
+			out = append(out, tag...)
+			rate = sampleRate
+		} else if sampleRate != rate {
+			return nil, fmt.Errorf("chunk %d: sample rate %d Hz does not match %d Hz", i+1, sampleRate, rate)
+		}
+		out = append(out, frames...)
+	}
+	return out, nil
+}
@@ -0,0 +1,113 @@
+package gospeak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultNormalizeLevel is the RMS level, in dBFS, that Normalize
+	// brings speech to by default.
+	DefaultNormalizeLevel = -20.0
+	// Peaks are kept at or below -1 dBFS, whatever the level
+	normalizePeak = -1.0
+)
+
+// Normalize makes MP3 or WAV audio as loud as level, an RMS level in dBFS
+// such as DefaultNormalizeLevel, and returns it as WAV. The gain is
+// limited so that no peak goes above -1 dBFS, so quiet clips with loud
+// peaks may end up below level. Silence is left as it is.
+func Normalize(audioData []byte, level float64) ([]byte, error) {
+	decoder, sampleRate, err := DecodeAudio(bytes.NewReader(audioData))
+	if err != nil {
+		return nil, err
+	}
+	pcm, err := io.ReadAll(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return encodeWAV(normalizePCM(pcm, level), sampleRate), nil
+}
+
+// normalizePCM returns 16-bit little-endian PCM scaled to an RMS level
+// of level dBFS, or with its peak at -1 dBFS if that is quieter.
+func normalizePCM(pcm []byte, level float64) []byte {
+	n := len(pcm) / 2
+	if n == 0 {
+		return pcm
+	}
+	var sum, peak float64
+	for i := range n {
+		s := float64(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+		sum += s * s
+		peak = max(peak, math.Abs(s))
+	}
+	if peak == 0 {
+		return pcm
+	}
+	rms := math.Sqrt(sum / float64(n))
+	gain := min(dbToAmplitude(level)*32768/rms, dbToAmplitude(normalizePeak)*32768/peak)
+
+	out := make([]byte, n*2)
+	for i := range n {
+		s := float64(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) * gain
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(max(min(s, math.MaxInt16), math.MinInt16))))
+	}
+	return out
+}
+
+func dbToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// DefaultSilenceThreshold is the level, in dBFS, below which TrimSilence
+// treats audio as silent by default.
+const DefaultSilenceThreshold = -50.0
+
+// TrimSilence removes the silence a provider leaves at the start and end
+// of MP3 or WAV audio, and returns it as WAV. Audio quieter than
+// threshold dBFS, such as DefaultSilenceThreshold, counts as silence.
+func TrimSilence(audioData []byte, threshold float64) ([]byte, error) {
+	decoder, sampleRate, err := DecodeAudio(bytes.NewReader(audioData))
+	if err != nil {
+		return nil, err
+	}
+	pcm, err := io.ReadAll(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return encodeWAV(trimSilencePCM(pcm, threshold), sampleRate), nil
+}
+
+// trimSilencePCM drops the leading and trailing frames of 16-bit
+// little-endian stereo PCM in which both channels are below threshold
+// dBFS. Audio that is silent throughout is returned as it is.
+func trimSilencePCM(pcm []byte, threshold float64) []byte {
+	const frameSize = 4
+	limit := dbToAmplitude(threshold) * 32768
+	loud := func(frame int) bool {
+		for ch := range 2 {
+			s := float64(int16(binary.LittleEndian.Uint16(pcm[frame*frameSize+ch*2:])))
+			if math.Abs(s) >= limit {
+				return true
+			}
+		}
+		return false
+	}
+
+	frames := len(pcm) / frameSize
+	start, end := 0, frames
+	for start < end && !loud(start) {
+		start++
+	}
+	if start == end {
+		return pcm
+	}
+	for !loud(end - 1) {
+		end--
+	}
+	return pcm[start*frameSize : end*frameSize]
+}
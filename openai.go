@@ -0,0 +1,103 @@
+package gospeak
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+const (
+	// OpenAI defaults
+	DefaultOpenAIVoice   = "alloy"
+	DefaultOpenAIModel   = "tts-1-hd"
+	OpenAIMaxChars       = 4096
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	openAISpeechPath     = "/audio/speech"
+)
+
+// OpenAIVoices lists the built-in OpenAI voices.
+var OpenAIVoices = []string{"alloy", "echo", "fable", "onyx", "nova", "shimmer"}
+
+// OpenAI TTS request
+type OpenAITTSRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format"`
+	Speed          float64 `json:"speed"`
+	Instructions   string  `json:"instructions,omitempty"`
+}
+
+// OpenAISupportsInstructions reports whether model takes voice
+// instructions. The original tts-1 models don't; gpt-4o-mini-tts and its
+// successors do.
+func OpenAISupportsInstructions(model string) bool {
+	return model != "tts-1" && model != "tts-1-hd"
+}
+
+func IsValidOpenAIVoice(voice string) bool {
+	for _, v := range OpenAIVoices {
+		if v == resolveOpenAIVoice(voice) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveOpenAIVoice(voice string) string {
+	// Match the built-in names regardless of case, like the other
+	// providers' presets
+	for _, v := range OpenAIVoices {
+		if strings.EqualFold(v, voice) {
+			return v
+		}
+	}
+	// Otherwise pass it on as given, for compatible servers with voices of
+	// their own
+	return voice
+}
+
+// OpenAIProvider synthesizes speech with the OpenAI audio API, or any
+// server that implements the same request schema.
+type OpenAIProvider struct {
+	APIKey string
+	// BaseURL is the API root, such as http://localhost:8080/v1, or the
+	// full speech endpoint. Empty means OpenAI itself.
+	BaseURL string
+	// Instructions steer the delivery, e.g. "speak cheerfully and
+	// slowly". They are sent only to models that support them.
+	Instructions string
+	HTTPOptions
+}
+
+// speechURL returns the speech endpoint under p.BaseURL.
+func (p OpenAIProvider) speechURL() string {
+	base := strings.TrimRight(p.BaseURL, "/")
+	if base == "" {
+		base = DefaultOpenAIBaseURL
+	}
+	if strings.HasSuffix(base, openAISpeechPath) {
+		return base
+	}
+	return base + openAISpeechPath
+}
+
+func (p OpenAIProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p OpenAIProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultOpenAIVoice, DefaultOpenAIModel, DefaultFormat("openai"))
+	reqBody := OpenAITTSRequest{
+		Model:          req.Model,
+		Input:          req.Text,
+		Voice:          resolveOpenAIVoice(req.Voice),
+		ResponseFormat: formatToken("openai", req.Format),
+		Speed:          req.Speed,
+	}
+	if OpenAISupportsInstructions(req.Model) {
+		reqBody.Instructions = p.Instructions
+	}
+
+	return p.post(ctx, p.speechURL(), bearerAuth(p.APIKey), reqBody)
+}
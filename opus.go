@@ -0,0 +1,148 @@
+package gospeak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/thesyncim/gopus"
+	"github.com/thesyncim/gopus/container/ogg"
+)
+
+// opusSampleRate is the rate Opus decodes at, whatever the encoder was
+// given.
+const opusSampleRate = 48000
+
+// isOggOpus reports whether header starts an Ogg stream carrying Opus:
+// the first page's first packet is the OpusHead identification header.
+func isOggOpus(header []byte) bool {
+	if len(header) < 27 || string(header[0:4]) != "OggS" {
+		return false
+	}
+	// The page header is 27 bytes, then one lacing value per segment
+	start := 27 + int(header[26])
+	return len(header) >= start+8 && string(header[start:start+8]) == "OpusHead"
+}
+
+// opusDecoder decodes Ogg Opus to 16-bit stereo PCM at 48 kHz, the
+// layout the rest of the package works in. Mono streams are copied to
+// both channels by the decoder. The encoder's pre-skip is dropped from
+// the start, and the last page's granule position trims the padding at
+// the end, so the audio is exactly as long as what was encoded.
+type opusDecoder struct {
+	ogg     *ogg.Reader
+	dec     *gopus.Decoder
+	pcm     []int16
+	pending []byte // decoded bytes not yet read
+	skip    int    // samples still to drop from the start
+	decoded uint64 // samples per channel decoded so far, pre-skip included
+}
+
+func newOpusDecoder(r io.Reader) (*opusDecoder, error) {
+	or, err := ogg.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if or.Header.MappingFamily != 0 {
+		return nil, fmt.Errorf("unsupported Opus channel mapping family %d; only mono and stereo are supported", or.Header.MappingFamily)
+	}
+	dec, err := gopus.NewDecoder(gopus.DefaultDecoderConfig(opusSampleRate, 2))
+	if err != nil {
+		return nil, err
+	}
+	if or.Header.OutputGain != 0 {
+		if err := dec.SetGain(int(or.Header.OutputGain)); err != nil {
+			return nil, err
+		}
+	}
+	return &opusDecoder{
+		ogg: or,
+		dec: dec,
+		// Room for the longest packet Opus allows, 120 ms of stereo
+		pcm:  make([]int16, opusSampleRate*120/1000*2),
+		skip: int(or.PreSkip()),
+	}, nil
+}
+
+func (d *opusDecoder) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		packet, granule, err := d.ogg.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read Ogg page: %w", err)
+		}
+		n, err := d.dec.DecodeInt16(packet, d.pcm)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode Opus packet: %w", err)
+		}
+
+		// A packet's granule position is where its audio ends; the last
+		// one is usually short of the packet's full length
+		end := d.decoded + uint64(n)
+		if granule > 0 && granule < end {
+			end = max(granule, d.decoded)
+		}
+		n = int(end - d.decoded)
+		d.decoded = end
+
+		start := min(d.skip, n)
+		d.skip -= start
+		d.pending = d.pending[:0]
+		for _, s := range d.pcm[start*2 : n*2] {
+			d.pending = binary.LittleEndian.AppendUint16(d.pending, uint16(s))
+		}
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// concatOpus joins Ogg Opus clips by copying their packets into a single
+// stream, so nothing is decoded or re-encoded. The first clip's header is
+// kept; the later clips' pre-skip and end padding stay in, a few
+// milliseconds at each join.
+func concatOpus(chunks [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w *ogg.Writer
+	for i, chunk := range chunks {
+		r, err := ogg.NewReader(bytes.NewReader(chunk))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Opus clip %d: %w", i+1, err)
+		}
+		if w == nil {
+			w, err = ogg.NewWriterWithConfig(&buf, ogg.WriterConfig{
+				SampleRate: r.SampleRate(),
+				Channels:   r.Channels(),
+				PreSkip:    r.PreSkip(),
+				OutputGain: r.Header.OutputGain,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		for {
+			packet, _, err := r.ReadPacket()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Opus clip %d: %w", i+1, err)
+			}
+			info, err := gopus.ParsePacket(packet)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Opus clip %d: %w", i+1, err)
+			}
+			if err := w.WritePacket(packet, info.FrameCount*info.TOC.FrameSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,71 @@
+package gospeak
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultPiperBin is the piper executable looked up on the PATH.
+const DefaultPiperBin = "piper"
+
+// PiperProvider synthesizes speech offline by running a local piper
+// binary. Request.Model is the path to the .onnx voice model and is
+// required; Request.Voice is ignored.
+type PiperProvider struct {
+	Bin string // defaults to piper on the PATH
+}
+
+// Command returns the piper invocation Synthesize runs for req, without
+// starting it. Its stdin is set to the text; stdout and stderr are left
+// for the caller.
+func (p PiperProvider) Command(ctx context.Context, req Request) (*exec.Cmd, error) {
+	req = req.withDefaults("", "", DefaultFormat("piper"))
+	if req.Model == "" {
+		return nil, errors.New("piper needs the path to a .onnx voice model as the model")
+	}
+	bin := p.Bin
+	if bin == "" {
+		bin = DefaultPiperBin
+	}
+
+	args := []string{"--model", req.Model, "--output_file", "-"}
+	if req.Format == "pcm" {
+		args = []string{"--model", req.Model, "--output-raw"}
+	}
+	if req.Speed != DefaultSpeed {
+		args = append(args, "--length_scale", strconv.FormatFloat(1/req.Speed, 'f', 3, 64))
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = strings.NewReader(req.Text)
+	return cmd, nil
+}
+
+// Synthesize runs piper, feeding the text on stdin and capturing the WAV
+// (or raw PCM) it writes to stdout. Piper expresses speed as a length
+// scale, so faster speech means a smaller scale.
+func (p PiperProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	req = req.withDefaults("", "", DefaultFormat("piper"))
+	cmd, err := p.Command(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("piper failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("piper failed: %w", err)
+	}
+	if req.Format == "wav" && !isWAV(stdout.Bytes()) {
+		return nil, errors.New("piper did not produce WAV audio")
+	}
+	return stdout.Bytes(), nil
+}
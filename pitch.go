@@ -0,0 +1,131 @@
+package gospeak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MaxPitch is the largest shift, in semitones up or down, PitchShift
+// accepts. Beyond an octave speech no longer sounds like speech.
+const MaxPitch = 12.0
+
+// PitchShift raises MP3 or WAV audio by semitones (negative lowers it)
+// without changing its length, and returns the result as WAV. The audio
+// is stretched in time and then resampled back to its original length,
+// so it works the same whichever provider made it.
+func PitchShift(audioData []byte, semitones float64) ([]byte, error) {
+	if math.Abs(semitones) > MaxPitch {
+		return nil, fmt.Errorf("pitch shift of %g semitones is outside ±%g", semitones, MaxPitch)
+	}
+	decoder, sampleRate, err := DecodeAudio(bytes.NewReader(audioData))
+	if err != nil {
+		return nil, err
+	}
+	pcm, err := io.ReadAll(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return encodeWAV(pitchShiftPCM(pcm, sampleRate, semitones), sampleRate), nil
+}
+
+// pitchShiftPCM shifts 16-bit little-endian stereo PCM by semitones,
+// keeping its length.
+func pitchShiftPCM(pcm []byte, sampleRate int, semitones float64) []byte {
+	if semitones == 0 {
+		return pcm
+	}
+	factor := math.Pow(2, semitones/12)
+	// Stretching by factor and then playing factor times as fast leaves
+	// the length as it was and scales every frequency by factor
+	return resamplePCM(stretchPCM(pcm, sampleRate, factor), int(factor*speedScale), speedScale)
+}
+
+// stretchPCM makes 16-bit little-endian stereo PCM factor times as long
+// without changing its pitch, by WSOLA: windows of the input are
+// overlap-added at a fixed hop in the output, each taken from near where
+// it would fall in time, shifted to line up with the waveform written so
+// far so the overlaps don't cancel out.
+func stretchPCM(pcm []byte, sampleRate int, factor float64) []byte {
+	const frameSize = 4
+	inFrames := len(pcm) / frameSize
+	window := max(sampleRate*40/1000, 64) // 40 ms, a few pitch periods of speech
+	hop := window / 2
+	tolerance := hop / 2
+	if inFrames < window+tolerance {
+		return pcm
+	}
+
+	in := make([][2]float64, inFrames)
+	for i := range in {
+		for ch := 0; ch < 2; ch++ {
+			in[i][ch] = float64(int16(binary.LittleEndian.Uint16(pcm[i*frameSize+ch*2:])))
+		}
+	}
+	mono := func(i int) float64 { return in[i][0] + in[i][1] }
+
+	// A periodic Hann window at half overlap sums to one; the sums are
+	// still kept to even out the first and last windows
+	hann := make([]float64, window)
+	for i := range hann {
+		hann[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(window))
+	}
+	outFrames := int(float64(inFrames) * factor)
+	out := make([][2]float64, outFrames+window)
+	weight := make([]float64, outFrames+window)
+
+	prev := 0
+	for k := 0; ; k++ {
+		outPos := k * hop
+		if outPos >= outFrames {
+			break
+		}
+
+		// Find the window near the ideal position that best continues
+		// the one laid down before it
+		pos := int(float64(outPos) / factor)
+		if k > 0 {
+			natural := prev + hop
+			best, bestScore := pos, math.Inf(-1)
+			for p := max(pos-tolerance, 0); p <= pos+tolerance; p++ {
+				if p+hop > inFrames || natural+hop > inFrames {
+					break
+				}
+				score := 0.0
+				for i := 0; i < hop; i += 2 {
+					score += mono(p+i) * mono(natural+i)
+				}
+				if score > bestScore {
+					best, bestScore = p, score
+				}
+			}
+			pos = best
+		}
+		if pos+window > inFrames {
+			pos = max(inFrames-window, 0)
+		}
+		prev = pos
+
+		for i := 0; i < window; i++ {
+			w := hann[i]
+			out[outPos+i][0] += in[pos+i][0] * w
+			out[outPos+i][1] += in[pos+i][1] * w
+			weight[outPos+i] += w
+		}
+	}
+
+	result := make([]byte, outFrames*frameSize)
+	for i := 0; i < outFrames; i++ {
+		for ch := 0; ch < 2; ch++ {
+			s := out[i][ch]
+			if weight[i] > 1e-3 {
+				s /= weight[i]
+			}
+			s = max(math.MinInt16, min(s, math.MaxInt16))
+			binary.LittleEndian.PutUint16(result[i*frameSize+ch*2:], uint16(int16(s)))
+		}
+	}
+	return result
+}
@@ -0,0 +1,289 @@
+package gospeak
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// The audio device is always opened for 16-bit little-endian stereo,
+// which is what DecodeAudio produces whatever the channel layout and
+// sample format of the source.
+const (
+	deviceChannels = 2
+	deviceFormat   = oto.FormatSignedInt16LE
+
+	// AutoPlaybackRate, given to SetPlaybackRate, opens the device at
+	// the source rate if it is one of standardDeviceRates, and at 48000
+	// Hz otherwise.
+	AutoPlaybackRate = -1
+
+	// deviceDrain is how long the device may still be playing after oto
+	// has handed it the last of a clip; its own buffer isn't visible.
+	deviceDrain = time.Second
+)
+
+// standardDeviceRates are the rates practically every audio device
+// accepts.
+var standardDeviceRates = []int{48000, 44100}
+
+// The oto library permits only one context per process, so it is created
+// once, at the sample rate of the first clip played, and shared by every
+// playback after that.
+var (
+	otoOnce     sync.Once
+	otoCtx      *oto.Context
+	otoRate     int
+	otoErr      error
+	audioFailed bool
+
+	// playbackRate is set by SetPlaybackRate. Zero plays at the source rate.
+	playbackRate int
+
+	// volume is set by SetVolume.
+	volume = 1.0
+
+	// normalizeLevel is set by SetNormalizeLevel. Zero leaves the level alone.
+	normalizeLevel float64
+
+	// silenceThreshold is set by SetTrimSilence. Zero keeps the silence.
+	silenceThreshold float64
+
+	// pitch is set by SetPitch, in semitones.
+	pitch float64
+
+	// playCommand is set by SetPlayCommand.
+	playCommand []string
+
+	// drainUntil is when the device will have played everything handed
+	// to it so far.
+	drainUntil time.Time
+)
+
+// SetPlaybackRate fixes the sample rate the audio device is opened at;
+// audio recorded at any other rate is resampled to it. Zero, the
+// default, opens the device at the rate of the first clip played, and
+// AutoPlaybackRate at a standard rate, for devices that reject others.
+// oto can only try to open the device once, so a rejected rate can't be
+// retried at another. It must be called before anything is played.
+func SetPlaybackRate(rate int) {
+	playbackRate = rate
+}
+
+// SetVolume scales the amplitude of everything played by gain: 0.5 is
+// half as loud, 0 is silent. A gain over 1 amplifies, clipping any
+// samples that would overflow. It leaves the system volume alone.
+func SetVolume(gain float64) {
+	volume = max(gain, 0)
+}
+
+// SetNormalizeLevel normalizes each clip played to level dBFS, as
+// Normalize does, before the volume is applied. Since the whole clip is
+// measured first, playback waits for the download to finish. Zero, the
+// default, plays clips at the level they were recorded.
+func SetNormalizeLevel(level float64) {
+	normalizeLevel = level
+}
+
+// SetTrimSilence trims the silence from the start and end of each clip
+// played, as TrimSilence does with threshold. Like normalization, this
+// waits for the whole clip. Zero, the default, plays clips untrimmed.
+func SetTrimSilence(threshold float64) {
+	silenceThreshold = threshold
+}
+
+// SetPitch shifts each clip played up by semitones, or down if it is
+// negative, as PitchShift does, keeping its length. Like normalization,
+// this waits for the whole clip. Zero, the default, leaves the pitch
+// alone; shifts beyond MaxPitch are limited to it.
+func SetPitch(semitones float64) {
+	pitch = max(-MaxPitch, min(semitones, MaxPitch))
+}
+
+// SetPlayCommand hands playback to an external player instead of the audio
+// device: command, such as ffplay -nodisp -autoexit -, is run for each clip
+// with the audio on its stdin. Play and PlayStream pass the audio on as it
+// is; PlayPCM wraps it in a WAV header. The volume, playback rate,
+// normalization, silence trimming, and pitch don't apply. An empty command
+// restores the audio device.
+func SetPlayCommand(command []string) {
+	playCommand = command
+}
+
+// DeviceFailed reports whether opening the audio device failed, in which
+// case every later playback fails too.
+func DeviceFailed() bool {
+	return audioFailed
+}
+
+// Play decodes and plays MP3, WAV, or Ogg Opus audio, returning once it has
+// finished or ctx is cancelled.
+func Play(ctx context.Context, audioData []byte) error {
+	return PlayStream(ctx, bytes.NewReader(audioData))
+}
+
+// PlayQueued is Play for a clip that another will follow straight away: it
+// returns as soon as the device has been handed the whole clip, instead of
+// waiting for the device to play it out, so clips play back to back
+// without a pause. Call Drain after the last one.
+func PlayQueued(ctx context.Context, audioData []byte) error {
+	return playStream(ctx, bytes.NewReader(audioData), false)
+}
+
+// Drain waits until the device has played out everything handed to it,
+// after PlayQueued. It returns early with ctx's error if ctx is cancelled.
+func Drain(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(drainUntil)):
+		return nil
+	}
+}
+
+// PlayStream decodes and plays MP3, WAV, or Ogg Opus audio as it arrives
+// from r, so playback can start before the download finishes.
+func PlayStream(ctx context.Context, r io.Reader) error {
+	return playStream(ctx, r, true)
+}
+
+func playStream(ctx context.Context, r io.Reader, drain bool) error {
+	if len(playCommand) > 0 {
+		return runPlayCommand(ctx, r)
+	}
+	pcm, sampleRate, err := DecodeAudio(r)
+	if err != nil {
+		return err
+	}
+
+	return playPCM(ctx, pcm, sampleRate, drain)
+}
+
+// audioContext returns the shared oto context and its sample rate,
+// creating it at sampleRate on first use.
+func audioContext(sampleRate int) (*oto.Context, int, error) {
+	otoOnce.Do(func() {
+		op := &oto.NewContextOptions{
+			SampleRate:   sampleRate,
+			ChannelCount: deviceChannels,
+			Format:       deviceFormat,
+		}
+
+		ctx, readyChan, err := oto.NewContext(op)
+		if err != nil {
+			audioFailed = true
+			otoErr = fmt.Errorf("failed to create audio context at %d Hz: %w", sampleRate, err)
+			return
+		}
+		<-readyChan
+		otoCtx, otoRate = ctx, sampleRate
+	})
+	return otoCtx, otoRate, otoErr
+}
+
+// PlayPCM plays 16-bit little-endian stereo PCM recorded at sampleRate,
+// resampling it if the shared context runs at a different rate. If ctx is
+// cancelled, playback stops at once and ctx's error is returned.
+func PlayPCM(ctx context.Context, source io.Reader, sampleRate int) error {
+	return playPCM(ctx, source, sampleRate, true)
+}
+
+// playPCM is PlayPCM, returning as soon as the device has been handed all
+// of source unless drain is set.
+func playPCM(ctx context.Context, source io.Reader, sampleRate int, drain bool) error {
+	if len(playCommand) > 0 {
+		pcm, err := io.ReadAll(source)
+		if err != nil {
+			return fmt.Errorf("failed to decode audio: %w", err)
+		}
+		return runPlayCommand(ctx, bytes.NewReader(encodeWAV(pcm, sampleRate)))
+	}
+
+	// Honor a fixed device rate if one was requested
+	target := sampleRate
+	if playbackRate > 0 {
+		target = playbackRate
+	} else if playbackRate == AutoPlaybackRate && !slices.Contains(standardDeviceRates, sampleRate) {
+		target = standardDeviceRates[0]
+	}
+
+	device, rate, err := audioContext(target)
+	if err != nil {
+		return err
+	}
+	if rate != sampleRate || normalizeLevel != 0 || silenceThreshold != 0 || pitch != 0 {
+		pcm, err := io.ReadAll(source)
+		if err != nil {
+			return fmt.Errorf("failed to decode audio: %w", err)
+		}
+		if silenceThreshold != 0 {
+			pcm = trimSilencePCM(pcm, silenceThreshold)
+		}
+		pcm = pitchShiftPCM(pcm, sampleRate, pitch)
+		pcm = resamplePCM(pcm, sampleRate, rate)
+		if normalizeLevel != 0 {
+			pcm = normalizePCM(pcm, normalizeLevel)
+		}
+		source = bytes.NewReader(pcm)
+	}
+	if volume != 1 {
+		source = &volumeReader{r: source, gain: volume}
+	}
+
+	// Create player and play
+	player := device.NewPlayer(source)
+	defer player.Close()
+
+	player.Play()
+
+	// Wait until the player has handed everything to the device
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for player.IsPlaying() || player.BufferedSize() > 0 {
+		select {
+		case <-ctx.Done():
+			player.Pause()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	drainUntil = time.Now().Add(deviceDrain)
+	if !drain {
+		return nil
+	}
+
+	// Allow audio buffer to fully drain
+	select {
+	case <-ctx.Done():
+		player.Pause()
+		return ctx.Err()
+	case <-time.After(deviceDrain):
+	}
+
+	return nil
+}
+
+// runPlayCommand pipes audio to the play command and waits for it to
+// exit. Anything the player prints goes to stderr, leaving stdout for
+// audio written with -o -.
+func runPlayCommand(ctx context.Context, audio io.Reader) error {
+	cmd := exec.CommandContext(ctx, playCommand[0], playCommand[1:]...)
+	cmd.Stdin = audio
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("play command %s: %w", playCommand[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package gospeak
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Play.ht defaults
+	DefaultPlayHTVoice  = "jennifer"
+	DefaultPlayHTEngine = "PlayHT2.0-turbo"
+	PlayHTMaxChars      = 2000
+	playHTAPIURL        = "https://api.play.ht/api/v2/tts/stream"
+	playHTVoicesURL     = "https://api.play.ht/api/v2/voices"
+)
+
+// Play.ht voice presets (name -> voice manifest URL)
+var PlayHTVoices = map[string]string{
+	"jennifer": "s3://voice-cloning-zero-shot/801a663f-efd0-4254-98d0-5c175514c3e8/jennifer/manifest.json",
+	"oliver":   "s3://peregrine-voices/oliver_narrative2_parrot_saad/manifest.json",
+}
+
+// MIME types to ask Play.ht for, by format
+var playHTAccept = map[string]string{
+	"mp3":  "audio/mpeg",
+	"wav":  "audio/wav",
+	"flac": "audio/flac",
+	"pcm":  "application/octet-stream",
+}
+
+// Play.ht TTS request
+type PlayHTTTSRequest struct {
+	Text         string  `json:"text"`
+	Voice        string  `json:"voice"`
+	VoiceEngine  string  `json:"voice_engine"`
+	OutputFormat string  `json:"output_format"`
+	Speed        float64 `json:"speed"`
+	Seed         uint32  `json:"seed,omitempty"`
+}
+
+// Play.ht GET /api/v2/voices response
+type PlayHTVoicesResponse []struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Accent   string `json:"accent"`
+	Gender   string `json:"gender"`
+	Language string `json:"language"`
+}
+
+func resolvePlayHTVoice(voice string) string {
+	// Check if it's a preset name
+	if id, ok := PlayHTVoices[strings.ToLower(voice)]; ok {
+		return id
+	}
+	// Otherwise assume it's a voice manifest URL
+	return voice
+}
+
+// playHTAuth sends the API key and the user ID it belongs to, both of
+// which Play.ht requires.
+type playHTAuth struct {
+	userID string
+	key    string
+}
+
+func (a playHTAuth) applyAuth(req *http.Request) {
+	req.Header.Set("X-User-Id", a.userID)
+	req.Header.Set("Authorization", a.key)
+}
+
+// PlayHTProvider synthesizes speech with the Play.ht API, using its
+// streaming endpoint so the audio comes back directly rather than as a
+// URL to fetch. Request.Model selects the voice engine.
+type PlayHTProvider struct {
+	APIKey string
+	UserID string
+	HTTPOptions
+}
+
+func (p PlayHTProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p PlayHTProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultPlayHTVoice, DefaultPlayHTEngine, DefaultFormat("playht"))
+	reqBody := PlayHTTTSRequest{
+		Text:         req.Text,
+		Voice:        resolvePlayHTVoice(req.Voice),
+		VoiceEngine:  req.Model,
+		OutputFormat: formatToken("playht", req.Format),
+		Speed:        req.Speed,
+		Seed:         req.Seed,
+	}
+	header := http.Header{}
+	header.Set("Accept", playHTAccept[req.Format])
+	return p.postWithHeader(ctx, playHTAPIURL, playHTAuth{userID: p.UserID, key: p.APIKey}, header, reqBody)
+}
+
+// Voices lists the Play.ht stock voices.
+func (p PlayHTProvider) Voices(ctx context.Context) ([]Voice, error) {
+	var resp PlayHTVoicesResponse
+	if err := p.get(ctx, playHTVoicesURL, playHTAuth{userID: p.UserID, key: p.APIKey}, &resp); err != nil {
+		return nil, err
+	}
+
+	voices := make([]Voice, len(resp))
+	for i, v := range resp {
+		var desc []string
+		for _, d := range []string{v.Language, v.Accent, v.Gender} {
+			if d != "" {
+				desc = append(desc, d)
+			}
+		}
+		voices[i] = Voice{Name: v.Name, ID: v.ID, Description: strings.Join(desc, ", ")}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
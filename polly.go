@@ -0,0 +1,107 @@
+package gospeak
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// AWS Polly defaults
+	DefaultPollyVoice  = "joanna"
+	DefaultPollyEngine = "neural"
+	DefaultPollyRegion = "us-east-1"
+	PollyMaxChars      = 3000
+	pollyAPIURL        = "https://polly.%s.amazonaws.com/v1/speech"
+)
+
+// Polly voice presets (lower-case name -> VoiceId)
+var PollyVoices = map[string]string{
+	"joanna":   "Joanna",
+	"matthew":  "Matthew",
+	"amy":      "Amy",
+	"brian":    "Brian",
+	"emma":     "Emma",
+	"ivy":      "Ivy",
+	"joey":     "Joey",
+	"justin":   "Justin",
+	"kendra":   "Kendra",
+	"kimberly": "Kimberly",
+	"salli":    "Salli",
+	"olivia":   "Olivia",
+	"aria":     "Aria",
+}
+
+// PollyEngines lists the Polly engines, which play the role of the model.
+var PollyEngines = []string{"neural", "standard"}
+
+// Polly SynthesizeSpeech request
+type PollyTTSRequest struct {
+	Engine       string `json:"Engine"`
+	OutputFormat string `json:"OutputFormat"`
+	Text         string `json:"Text"`
+	TextType     string `json:"TextType"`
+	VoiceId      string `json:"VoiceId"`
+}
+
+func resolvePollyVoice(voice string) string {
+	// Check if it's a preset name
+	if id, ok := PollyVoices[strings.ToLower(voice)]; ok {
+		return id
+	}
+	// Otherwise assume it's a Polly VoiceId
+	return voice
+}
+
+func IsValidPollyEngine(engine string) bool {
+	for _, e := range PollyEngines {
+		if e == engine {
+			return true
+		}
+	}
+	return false
+}
+
+// PollyProvider synthesizes speech with AWS Polly, signing requests with
+// AWS Signature Version 4. Request.Model selects the engine. Polly has no
+// speed control, so Request.Speed is ignored.
+type PollyProvider struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // for temporary credentials
+	Region       string // defaults to us-east-1
+	HTTPOptions
+}
+
+func (p PollyProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p PollyProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultPollyVoice, DefaultPollyEngine, DefaultFormat("polly"))
+	region := p.Region
+	if region == "" {
+		region = DefaultPollyRegion
+	}
+	reqBody := PollyTTSRequest{
+		Engine:       req.Model,
+		OutputFormat: formatToken("polly", req.Format),
+		Text:         req.Text,
+		TextType:     "text",
+		VoiceId:      resolvePollyVoice(req.Voice),
+	}
+	if req.SSML {
+		reqBody.Text = wrapSSML(req.Text)
+		reqBody.TextType = "ssml"
+	}
+
+	auth := sigV4Auth{
+		accessKey:    p.AccessKey,
+		secretKey:    p.SecretKey,
+		sessionToken: p.SessionToken,
+		region:       region,
+		service:      "polly",
+	}
+	return p.post(ctx, fmt.Sprintf(pollyAPIURL, region), auth, reqBody)
+}
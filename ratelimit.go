@@ -0,0 +1,62 @@
+package gospeak
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces requests with a token bucket: tokens are added at a
+// steady rate up to a burst size, and each request takes one, waiting
+// for it if the bucket is empty. One limiter can be shared by any number
+// of goroutines and providers, so a pool of workers stays within a
+// single request rate. A nil *RateLimiter never waits.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter that allows rps requests per second
+// on average and up to burst at once. The bucket starts full. A burst
+// below 1 is taken as 1, and an rps of zero or less never waits.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	b := float64(max(burst, 1))
+	return &RateLimiter{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent, or returns ctx's error if it
+// is cancelled first. Waiters are served in the order they called Wait.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	// Take a token now, going into debt if there is none; the debt is
+	// how long this caller has to wait
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst)
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the token back for the callers behind this one
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package gospeak
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// speedScale turns a speed factor into the integer rates resamplePCM
+// takes; only their ratio matters.
+const speedScale = 10000
+
+// ChangeSpeed speeds MP3 or WAV audio up (factor > 1) or slows it down by
+// resampling it, and returns the result as WAV. As with a tape played
+// faster, the pitch changes along with the speed. It is meant for
+// providers with no speed control of their own.
+func ChangeSpeed(audioData []byte, factor float64) ([]byte, error) {
+	decoder, sampleRate, err := DecodeAudio(bytes.NewReader(audioData))
+	if err != nil {
+		return nil, err
+	}
+	pcm, err := resample(decoder, factor)
+	if err != nil {
+		return nil, err
+	}
+	return encodeWAV(pcm, sampleRate), nil
+}
+
+// resample reads decoded 16-bit stereo PCM and returns it played factor
+// times as fast, at the same sample rate.
+func resample(decoder io.Reader, factor float64) ([]byte, error) {
+	pcm, err := io.ReadAll(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+	return resamplePCM(pcm, int(factor*speedScale), speedScale), nil
+}
+
+// resamplePCM converts 16-bit little-endian stereo PCM from one sample rate
+// to another using linear interpolation.
+func resamplePCM(pcm []byte, fromRate, toRate int) []byte {
+	const frameSize = 4
+	inFrames := len(pcm) / frameSize
+	if inFrames == 0 || fromRate == toRate {
+		return pcm
+	}
+
+	outFrames := int(int64(inFrames) * int64(toRate) / int64(fromRate))
+	out := make([]byte, outFrames*frameSize)
+	step := float64(fromRate) / float64(toRate)
+
+	sample := func(frame, ch int) float64 {
+		if frame >= inFrames {
+			frame = inFrames - 1
+		}
+		return float64(int16(binary.LittleEndian.Uint16(pcm[frame*frameSize+ch*2:])))
+	}
+
+	for i := 0; i < outFrames; i++ {
+		pos := float64(i) * step
+		idx := int(pos)
+		frac := pos - float64(idx)
+		for ch := 0; ch < 2; ch++ {
+			a := sample(idx, ch)
+			b := sample(idx+1, ch)
+			v := int16(a + (b-a)*frac)
+			binary.LittleEndian.PutUint16(out[i*frameSize+ch*2:], uint16(v))
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package gospeak
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Resemble limits
+	ResembleMaxChars = 2000
+	resembleAPIURL   = "https://f.cluster.resemble.ai/synthesize"
+	resembleVoiceURL = "https://app.resemble.ai/api/v2/voices"
+)
+
+// Resemble synthesize request
+type ResembleTTSRequest struct {
+	VoiceUUID    string `json:"voice_uuid"`
+	ProjectUUID  string `json:"project_uuid,omitempty"`
+	Data         string `json:"data"`
+	OutputFormat string `json:"output_format"`
+}
+
+// Resemble synthesize response. The audio comes back base64-encoded, or
+// as a link to download it from.
+type ResembleTTSResponse struct {
+	Success      bool     `json:"success"`
+	AudioContent string   `json:"audio_content"`
+	AudioSrc     string   `json:"audio_src"`
+	Issues       []string `json:"issues"`
+	Message      string   `json:"message"`
+}
+
+// Resemble GET /api/v2/voices response, one page of it
+type ResembleVoicesResponse struct {
+	Page     int `json:"page"`
+	NumPages int `json:"num_pages"`
+	Items    []struct {
+		UUID   string `json:"uuid"`
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"items"`
+}
+
+// ResembleProvider synthesizes speech with Resemble AI. Resemble names
+// voices and projects by UUID, so Request.Voice must be a voice UUID;
+// there is no default. Clips are saved to ProjectUUID when it is set.
+// Resemble reads SSML wrapped in <speak>; it has no speed control, and
+// the voice implies the model, so Request.Speed and Request.Model are
+// ignored.
+type ResembleProvider struct {
+	APIKey      string
+	ProjectUUID string // optional project to store the clips in
+	HTTPOptions
+}
+
+func (p ResembleProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	req = req.withDefaults("", "", DefaultFormat("resemble"))
+	if req.Voice == "" {
+		return nil, errors.New("resemble needs a voice UUID")
+	}
+
+	reqBody := ResembleTTSRequest{
+		VoiceUUID:    req.Voice,
+		ProjectUUID:  p.ProjectUUID,
+		Data:         req.Text,
+		OutputFormat: formatToken("resemble", req.Format),
+	}
+	body, err := readAudio(p.post(ctx, resembleAPIURL, bearerAuth(p.APIKey), reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp ResembleTTSResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !resp.Success {
+		msg := resp.Message
+		if msg == "" {
+			msg = strings.Join(resp.Issues, "; ")
+		}
+		return nil, fmt.Errorf("resemble synthesis failed: %s", msg)
+	}
+
+	var audioData []byte
+	switch {
+	case resp.AudioContent != "":
+		if audioData, err = base64.StdEncoding.DecodeString(resp.AudioContent); err != nil {
+			return nil, fmt.Errorf("failed to decode audio content: %w", err)
+		}
+	case resp.AudioSrc != "":
+		// The link is signed, so it needs no key
+		if audioData, err = readAudio(p.send(ctx, "GET", resp.AudioSrc, noAuth{}, nil, nil)); err != nil {
+			return nil, fmt.Errorf("failed to download audio: %w", err)
+		}
+	}
+	if len(audioData) < minAudioBytes {
+		return nil, shortAudioError(len(audioData))
+	}
+	return audioData, nil
+}
+
+// Voices lists the voices on the account, with the UUIDs to pass as
+// Request.Voice.
+func (p ResembleProvider) Voices(ctx context.Context) ([]Voice, error) {
+	var voices []Voice
+	for page := 1; ; page++ {
+		var resp ResembleVoicesResponse
+		query := url.Values{"page": {fmt.Sprint(page)}, "page_size": {"100"}}
+		if err := p.get(ctx, resembleVoiceURL+"?"+query.Encode(), bearerAuth(p.APIKey), &resp); err != nil {
+			return nil, err
+		}
+		for _, v := range resp.Items {
+			voices = append(voices, Voice{Name: v.Name, ID: v.UUID, Description: v.Status})
+		}
+		if len(resp.Items) == 0 || page >= resp.NumPages {
+			break
+		}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
@@ -0,0 +1,88 @@
+package gospeak
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSayBin is the macOS speech command.
+	DefaultSayBin = "say"
+	// DefaultSayRate is the rate say speaks at, in words per minute, when
+	// none is given.
+	DefaultSayRate = 175
+)
+
+// SayProvider synthesizes speech offline with the macOS say command.
+// Request.Voice is a macOS voice name such as Samantha; empty means the
+// system voice. Request.Model is ignored. It only works on macOS.
+type SayProvider struct {
+	Bin string // defaults to say on the PATH
+}
+
+// Command returns the say invocation Synthesize runs for req, writing
+// the audio to path, without starting it. Its stdin is set to the text.
+func (p SayProvider) Command(ctx context.Context, req Request, path string) (*exec.Cmd, error) {
+	req = req.withDefaults("", "", DefaultFormat("say"))
+	if runtime.GOOS != "darwin" {
+		return nil, errors.New("say is only available on macOS")
+	}
+	bin := p.Bin
+	if bin == "" {
+		bin = DefaultSayBin
+	}
+
+	// say writes AIFF by default; ask for 16-bit WAV, which gospeak plays
+	args := []string{"-f", "-", "-o", path, "--file-format=WAVE", "--data-format=LEI16@22050"}
+	if req.Voice != "" {
+		args = append(args, "-v", req.Voice)
+	}
+	if req.Speed != DefaultSpeed {
+		args = append(args, "-r", strconv.Itoa(int(DefaultSayRate*req.Speed+0.5)))
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = strings.NewReader(req.Text)
+	return cmd, nil
+}
+
+// Synthesize runs say, feeding the text on stdin, and reads back the WAV
+// it writes to a temporary file. Speed scales say's default rate of 175
+// words per minute.
+func (p SayProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "gospeak-say-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "speech.wav")
+
+	cmd, err := p.Command(ctx, req, path)
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("say failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("say failed: %w", err)
+	}
+
+	audioData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read say output: %w", err)
+	}
+	if !isWAV(audioData) {
+		return nil, errors.New("say did not produce WAV audio")
+	}
+	return audioData, nil
+}
@@ -0,0 +1,97 @@
+package gospeak
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceEnd matches terminal punctuation, any closing quotes or
+// brackets, and the whitespace that follows.
+var sentenceEnd = regexp.MustCompile(`[.!?…]+["'”’)\]]*\s+`)
+
+// wrapSSML wraps text in a <speak> root element unless it already has one,
+// as Google and Polly require a complete SSML document.
+func wrapSSML(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "<speak") {
+		return trimmed
+	}
+	return "<speak>" + trimmed + "</speak>"
+}
+
+// SplitText splits text into chunks of at most maxChars characters,
+// breaking between sentences where possible, then between words, and
+// only as a last resort inside a word. A maxChars of zero or less means
+// no limit.
+func SplitText(text string, maxChars int) []string {
+	text = strings.TrimSpace(text)
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var cur strings.Builder
+	curLen := 0
+	flush := func() {
+		if chunk := strings.TrimSpace(cur.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		cur.Reset()
+		curLen = 0
+	}
+	// Pieces carry only trailing whitespace, which doesn't count against
+	// the limit since chunks are trimmed
+	add := func(piece string) {
+		if curLen+utf8.RuneCountInString(strings.TrimRightFunc(piece, unicode.IsSpace)) > maxChars {
+			flush()
+		}
+		cur.WriteString(piece)
+		curLen += utf8.RuneCountInString(piece)
+	}
+
+	// Sentences keep their trailing whitespace, so paragraph breaks
+	// survive within a chunk
+	for _, sentence := range splitSentences(text) {
+		if utf8.RuneCountInString(strings.TrimSpace(sentence)) <= maxChars {
+			add(sentence)
+			continue
+		}
+		for _, word := range strings.Fields(sentence) {
+			for utf8.RuneCountInString(word) > maxChars {
+				runes := []rune(word)
+				add(string(runes[:maxChars]))
+				word = string(runes[maxChars:])
+			}
+			add(word + " ")
+		}
+	}
+	flush()
+	return chunks
+}
+
+// SplitSentences splits text into sentences at terminal punctuation, the
+// same breaks SplitText prefers. Sentences are trimmed, and blank ones
+// dropped.
+func SplitSentences(text string) []string {
+	var sentences []string
+	for _, sentence := range splitSentences(strings.TrimSpace(text)) {
+		if sentence = strings.TrimSpace(sentence); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+// splitSentences splits text after each sentenceEnd match, leaving the
+// whitespace that follows a sentence on the end of it.
+func splitSentences(text string) []string {
+	var sentences []string
+	last := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		sentences = append(sentences, text[last:loc[1]])
+		last = loc[1]
+	}
+	return append(sentences, text[last:])
+}
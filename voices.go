@@ -0,0 +1,66 @@
+package gospeak
+
+import (
+	"context"
+	"sort"
+)
+
+// Voice is one voice a provider offers.
+type Voice struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+}
+
+// VoiceLister is a Provider that can list the voices available to the
+// account from its API, including any custom ones.
+type VoiceLister interface {
+	Provider
+	Voices(ctx context.Context) ([]Voice, error)
+}
+
+// BuiltinVoices returns the voice presets built into gospeak for
+// provider, sorted by name. Piper has none, since its voices are model
+// files, and neither do say and espeak, which use the installed voices,
+// or Resemble, whose voices are UUIDs on the account.
+func BuiltinVoices(provider string) []Voice {
+	var presets map[string]string
+	switch provider {
+	case "openai":
+		voices := make([]Voice, len(OpenAIVoices))
+		for i, v := range OpenAIVoices {
+			voices[i] = Voice{Name: v, ID: v}
+		}
+		return voices
+	case "elevenlabs":
+		presets = ElevenLabsVoices
+	case "deepgram":
+		presets = DeepgramVoices
+	case "google":
+		presets = GoogleVoices
+	case "polly":
+		presets = PollyVoices
+	case "azure":
+		presets = AzureVoices
+	case "playht":
+		presets = PlayHTVoices
+	case "watson":
+		presets = WatsonVoices
+	case "lmnt":
+		presets = LMNTVoices
+	}
+	return presetVoices(presets)
+}
+
+func presetVoices(presets map[string]string) []Voice {
+	voices := make([]Voice, 0, len(presets))
+	for name, id := range presets {
+		voices = append(voices, Voice{Name: name, ID: id})
+	}
+	sortVoices(voices)
+	return voices
+}
+
+func sortVoices(voices []Voice) {
+	sort.Slice(voices, func(i, j int) bool { return voices[i].Name < voices[j].Name })
+}
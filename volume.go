@@ -0,0 +1,37 @@
+package gospeak
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// volumeReader scales 16-bit little-endian PCM read from r by gain,
+// clamping samples that would overflow.
+type volumeReader struct {
+	r    io.Reader
+	gain float64
+	odd  []byte // a trailing byte held back until its sample is complete
+}
+
+func (v *volumeReader) Read(p []byte) (int, error) {
+	if len(p) < 2 {
+		return 0, io.ErrShortBuffer
+	}
+	n := copy(p, v.odd)
+	v.odd = v.odd[:0]
+	m, err := v.r.Read(p[n:])
+	n += m
+
+	// Only whole samples can be scaled; keep a split one for next time
+	if n%2 == 1 {
+		v.odd = append(v.odd, p[n-1])
+		n--
+	}
+	for i := 0; i < n; i += 2 {
+		s := float64(int16(binary.LittleEndian.Uint16(p[i:]))) * v.gain
+		s = max(math.MinInt16, min(s, math.MaxInt16))
+		binary.LittleEndian.PutUint16(p[i:], uint16(int16(s)))
+	}
+	return n, err
+}
@@ -0,0 +1,127 @@
+package gospeak
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// IBM Watson Text to Speech defaults
+	DefaultWatsonVoice = "en-US_AllisonV3Voice"
+	WatsonMaxChars     = 5000
+)
+
+// Watson voice presets (short name -> voice name)
+var WatsonVoices = map[string]string{
+	"allison":      "en-US_AllisonV3Voice",
+	"emily":        "en-US_EmilyV3Voice",
+	"henry":        "en-US_HenryV3Voice",
+	"kevin":        "en-US_KevinV3Voice",
+	"lisa":         "en-US_LisaV3Voice",
+	"michael":      "en-US_MichaelV3Voice",
+	"olivia":       "en-US_OliviaV3Voice",
+	"uk-charlotte": "en-GB_CharlotteV3Voice",
+	"uk-james":     "en-GB_JamesV3Voice",
+	"uk-kate":      "en-GB_KateV3Voice",
+	"au-heidi":     "en-AU_HeidiExpressive",
+	"au-jack":      "en-AU_JackExpressive",
+}
+
+// Watson GET /v1/voices response
+type WatsonVoicesResponse struct {
+	Voices []struct {
+		Name        string `json:"name"`
+		Language    string `json:"language"`
+		Gender      string `json:"gender"`
+		Description string `json:"description"`
+	} `json:"voices"`
+}
+
+// Watson TTS request
+type WatsonTTSRequest struct {
+	Text string `json:"text"`
+}
+
+func resolveWatsonVoice(voice string) string {
+	// Check if it's a preset name
+	if name, ok := WatsonVoices[strings.ToLower(voice)]; ok {
+		return name
+	}
+	// Otherwise assume it's a full voice name (e.g., en-US_AllisonV3Voice)
+	return voice
+}
+
+// WatsonProvider synthesizes speech with IBM Watson Text to Speech. URL
+// is the service instance's URL, such as
+// https://api.us-south.text-to-speech.watson.cloud.ibm.com/instances/...,
+// shown with its API key in the IBM Cloud console. Watson reads SSML in
+// any text, so plain text is escaped. The voice name implies the model,
+// so Request.Model is ignored.
+type WatsonProvider struct {
+	APIKey string
+	URL    string // service instance URL; required
+	HTTPOptions
+}
+
+func (p WatsonProvider) Synthesize(ctx context.Context, req Request) ([]byte, error) {
+	return readAudio(p.Stream(ctx, req))
+}
+
+func (p WatsonProvider) Stream(ctx context.Context, req Request) (io.ReadCloser, error) {
+	req = req.withDefaults(DefaultWatsonVoice, "", DefaultFormat("watson"))
+	if p.URL == "" {
+		return nil, errors.New("watson needs the URL of the service instance")
+	}
+
+	text := req.Text
+	if !req.SSML {
+		var escaped strings.Builder
+		xml.EscapeText(&escaped, []byte(text))
+		text = escaped.String()
+	}
+	query := url.Values{"voice": {resolveWatsonVoice(req.Voice)}}
+	// Watson takes the speed as a percentage faster or slower than normal
+	if req.Speed != DefaultSpeed {
+		query.Set("rate_percentage", fmt.Sprint(int(math.Round((req.Speed-1)*100))))
+	}
+	header := http.Header{}
+	header.Set("Accept", formatToken("watson", req.Format))
+
+	endpoint := strings.TrimSuffix(p.URL, "/") + "/v1/synthesize?" + query.Encode()
+	return p.postWithHeader(ctx, endpoint, p.auth(), header, WatsonTTSRequest{Text: text})
+}
+
+// Voices lists the voices the service instance offers.
+func (p WatsonProvider) Voices(ctx context.Context) ([]Voice, error) {
+	if p.URL == "" {
+		return nil, errors.New("watson needs the URL of the service instance")
+	}
+	var resp WatsonVoicesResponse
+	if err := p.get(ctx, strings.TrimSuffix(p.URL, "/")+"/v1/voices", p.auth(), &resp); err != nil {
+		return nil, err
+	}
+
+	voices := make([]Voice, len(resp.Voices))
+	for i, v := range resp.Voices {
+		var desc []string
+		for _, d := range []string{v.Language, v.Gender} {
+			if d != "" {
+				desc = append(desc, d)
+			}
+		}
+		voices[i] = Voice{Name: v.Name, ID: v.Name, Description: strings.Join(desc, ", ")}
+	}
+	sortVoices(voices)
+	return voices, nil
+}
+
+func (p WatsonProvider) auth() authScheme {
+	return basicAuth{user: "apikey", password: p.APIKey}
+}
@@ -0,0 +1,258 @@
+package gospeak
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// isWAV reports whether header starts a RIFF/WAVE file.
+func isWAV(header []byte) bool {
+	return len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE"
+}
+
+// DecodeAudio sniffs the container at the start of r and returns a reader
+// of 16-bit little-endian stereo PCM along with its sample rate. WAV is
+// recognised by its RIFF header, and converted to stereo 16-bit from
+// whatever layout it has; Ogg Opus by its OpusHead packet, and decoded at
+// 48 kHz; anything else is treated as MP3, which go-mp3 always decodes to
+// stereo.
+func DecodeAudio(r io.Reader) (io.Reader, int, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(12)
+	if isWAV(header) {
+		d, err := newWAVDecoder(br)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode WAV: %w", err)
+		}
+		return d, d.sampleRate, nil
+	}
+	// The OpusHead packet follows the first page's lacing values, of
+	// which there can be up to 255
+	if header, _ = br.Peek(27 + 255 + 8); isOggOpus(header) {
+		d, err := newOpusDecoder(br)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode Opus: %w", err)
+		}
+		return d, opusSampleRate, nil
+	}
+
+	decoder, err := mp3.NewDecoder(br)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode MP3: %w", err)
+	}
+	return decoder, decoder.SampleRate(), nil
+}
+
+// AudioDuration returns the playback length of MP3, WAV, or Ogg Opus
+// audio. The length comes from the MP3 frame headers or the size of the
+// WAV data chunk, so usually nothing has to be decoded; Opus is decoded
+// in full.
+func AudioDuration(audioData []byte) (time.Duration, error) {
+	var pcm io.Reader
+	var sampleRate int
+	if isWAV(audioData) || isOggOpus(audioData) {
+		var err error
+		if pcm, sampleRate, err = DecodeAudio(bytes.NewReader(audioData)); err != nil {
+			return 0, err
+		}
+	} else {
+		// Given a seekable source, go-mp3 can find the length by reading
+		// the frame headers alone
+		decoder, err := mp3.NewDecoder(bytes.NewReader(audioData))
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode MP3: %w", err)
+		}
+		pcm, sampleRate = decoder, decoder.SampleRate()
+	}
+
+	n := int64(-1)
+	if l, ok := pcm.(interface{ Length() int64 }); ok {
+		n = l.Length()
+	}
+	if n < 0 {
+		var err error
+		if n, err = io.Copy(io.Discard, pcm); err != nil {
+			return 0, fmt.Errorf("failed to decode audio: %w", err)
+		}
+	}
+	// Decoded audio is always 16-bit stereo: 4 bytes per sample frame
+	frames := n / 4
+	return time.Duration(float64(frames) / float64(sampleRate) * float64(time.Second)), nil
+}
+
+// WAV format codes.
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavDecoder reads the samples of a WAV file and converts them to the
+// 16-bit stereo PCM that go-mp3 produces and the audio device is opened
+// with. Mono is copied to both channels, and files with more than two
+// channels keep the front left and right. 8, 16, 24, and 32-bit integer
+// and 32-bit float samples are supported.
+type wavDecoder struct {
+	r          *bufio.Reader
+	sampleRate int
+	channels   int
+	float      bool
+	width      int   // bytes per sample
+	remaining  int64 // bytes left in the data chunk, or -1 if unknown
+	frame      []byte
+}
+
+func newWAVDecoder(r *bufio.Reader) (*wavDecoder, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if !isWAV(riff[:]) {
+		return nil, errors.New("not a WAV file")
+	}
+
+	d := &wavDecoder{r: r}
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, errors.New("missing data chunk")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("invalid fmt chunk")
+			}
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return nil, err
+			}
+			format := binary.LittleEndian.Uint16(fmtChunk[0:2])
+			d.channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			d.sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bits := int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			// The extensible header carries the real format code at the
+			// start of its sub-format GUID
+			if format == wavFormatExtensible && size >= 40 {
+				format = binary.LittleEndian.Uint16(fmtChunk[24:26])
+			}
+			switch {
+			case format == wavFormatPCM && (bits == 8 || bits == 16 || bits == 24 || bits == 32):
+			case format == wavFormatFloat && bits == 32:
+				d.float = true
+			default:
+				return nil, fmt.Errorf("unsupported WAV encoding (format %d, %d-bit); only 8 to 32-bit PCM and 32-bit float are supported", format, bits)
+			}
+			if d.channels < 1 {
+				return nil, fmt.Errorf("invalid WAV channel count %d", d.channels)
+			}
+			d.width = bits / 8
+		case "data":
+			if d.sampleRate == 0 {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			// Streamed WAVs often leave the size as zero or all ones
+			d.remaining = size
+			if size == 0 || size == 0xFFFFFFFF {
+				d.remaining = -1
+			}
+			d.frame = make([]byte, d.width*d.channels)
+			return d, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Length returns the number of bytes of PCM left to read, or -1 if the
+// size of the data chunk is unknown.
+func (d *wavDecoder) Length() int64 {
+	if d.remaining < 0 {
+		return -1
+	}
+	return d.remaining / int64(d.channels*d.width) * 4
+}
+
+func (d *wavDecoder) Read(p []byte) (int, error) {
+	// Frames are decoded whole, to 4 bytes each
+	if len(p) < 4 {
+		return 0, io.ErrShortBuffer
+	}
+	n := 0
+	for n+4 <= len(p) {
+		// The data chunk ends here, or in a partial frame that can't be
+		// played; whatever follows is another chunk, not audio
+		if d.remaining >= 0 && d.remaining < int64(len(d.frame)) {
+			d.remaining = 0
+			break
+		}
+		if _, err := io.ReadFull(d.r, d.frame); err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		}
+		if d.remaining > 0 {
+			d.remaining -= int64(len(d.frame))
+		}
+		left := d.sample(0)
+		right := left
+		if d.channels > 1 {
+			right = d.sample(1)
+		}
+		binary.LittleEndian.PutUint16(p[n:], uint16(left))
+		binary.LittleEndian.PutUint16(p[n+2:], uint16(right))
+		n += 4
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// sample returns channel ch of the current frame as a 16-bit sample.
+func (d *wavDecoder) sample(ch int) int16 {
+	b := d.frame[ch*d.width : (ch+1)*d.width]
+	switch {
+	case d.float:
+		f := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		return int16(max(-1, min(f, 1)) * math.MaxInt16)
+	case d.width == 1:
+		// 8-bit WAV is unsigned
+		return (int16(b[0]) - 128) << 8
+	default:
+		// Keep the most significant 16 bits
+		return int16(binary.LittleEndian.Uint16(b[d.width-2:]))
+	}
+}
+
+// encodeWAV wraps 16-bit little-endian stereo PCM in a WAV header.
+func encodeWAV(pcm []byte, sampleRate int) []byte {
+	const channels, bits = 2, 16
+	out := make([]byte, 44, 44+len(pcm))
+	copy(out[0:], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(36+len(pcm)))
+	copy(out[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(out[16:], 16)
+	binary.LittleEndian.PutUint16(out[20:], 1)
+	binary.LittleEndian.PutUint16(out[22:], channels)
+	binary.LittleEndian.PutUint32(out[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(out[28:], uint32(sampleRate*channels*bits/8))
+	binary.LittleEndian.PutUint16(out[32:], channels*bits/8)
+	binary.LittleEndian.PutUint16(out[34:], bits)
+	copy(out[36:], "data")
+	binary.LittleEndian.PutUint32(out[40:], uint32(len(pcm)))
+	return append(out, pcm...)
+}
@@ -0,0 +1,191 @@
+package gospeak
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2)
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// Largest message wsConn accepts, well above any audio chunk
+const wsMaxMessage = 16 << 20
+
+// wsConn is a client WebSocket connection with just what the streaming
+// APIs need: whole messages in and out, and answers to pings.
+type wsConn struct {
+	conn io.ReadWriteCloser
+	r    *bufio.Reader
+	mu   sync.Mutex // serializes writes
+}
+
+// dialWebSocket opens a WebSocket to url, a wss:// or ws:// URL,
+// authenticated with auth. The handshake goes through the HTTP client, so
+// it uses the same proxy and TLS settings as the other requests, but not
+// its timeout: a stream lasts as long as ctx. A refused handshake is
+// reported as an APIError.
+func (o HTTPOptions) dialWebSocket(ctx context.Context, url string, auth authScheme) (*wsConn, error) {
+	url = strings.Replace(strings.Replace(url, "wss://", "https://", 1), "ws://", "http://", 1)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if err := o.Limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	auth.applyAuth(req)
+
+	client := *o.client()
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: apiErrorMessage(body), Body: body}
+	}
+	// The body of a 101 response is the connection itself
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("failed to open WebSocket: connection can't be upgraded")
+	}
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, errors.New("failed to open WebSocket: bad handshake response")
+	}
+	return &wsConn{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// writeMessage sends data as a single text message.
+func (c *wsConn) writeMessage(data []byte) error {
+	return c.writeFrame(wsText, data)
+}
+
+// writeFrame sends one final frame. Client frames must be masked.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	header = append(header, mask...)
+	frame := append(header, payload...)
+	for i := range payload {
+		frame[len(header)+i] ^= mask[i%4]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// readMessage returns the next text or binary message, answering pings
+// on the way. It returns io.EOF when the server closes the connection
+// normally.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.r, head[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := head[0]&0x80 != 0, head[0]&0x0F
+		n := uint64(head[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if n > wsMaxMessage || uint64(len(message))+n > wsMaxMessage {
+			return nil, errors.New("WebSocket message too large")
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+		case wsClose:
+			c.writeFrame(wsClose, payload)
+			return nil, closeError(payload)
+		case wsText, wsBinary, wsContinuation:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unexpected WebSocket opcode %d", opcode)
+		}
+	}
+}
+
+// closeError turns the payload of a close frame into io.EOF for a normal
+// close, or an error carrying the server's reason.
+func closeError(payload []byte) error {
+	if len(payload) < 2 {
+		return io.EOF
+	}
+	code := binary.BigEndian.Uint16(payload)
+	if code == 1000 {
+		return io.EOF
+	}
+	if reason := string(payload[2:]); reason != "" {
+		return fmt.Errorf("WebSocket closed: %s (code %d)", reason, code)
+	}
+	return fmt.Errorf("WebSocket closed with code %d", code)
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}